@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrAckTimeout = errors.New("Acknowledgement deadline exceeded")
+
+type ackKey struct{}
+
+// The acknowledgement deadline of a single call to a Handler
+type ack struct {
+	clock    Clock
+	deadline time.Duration
+	done     chan struct{}
+
+	sync.Mutex
+	due     time.Time
+	acked   bool
+	expired bool
+}
+
+// AckDeadline puts a Handler in ack mode: returning acknowledges the
+// notification, and a call that neither returns nor calls KeepAlive within
+// deadline is treated as failed. Its context is then cancelled with
+// ErrAckTimeout as its cause (see context.Cause) and it returns ErrAckTimeout,
+// whatever it returns once it does, so StartRetrying redelivers the
+// notification through its retry tiers. Handlers doing long work call
+// KeepAlive every so often to push the deadline out. The deadline is told by
+// the notifier's clock
+func AckDeadline(deadline time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, data interface{}) error {
+			clock := ClockFromContext(ctx)
+			a := &ack{clock: clock, deadline: deadline, done: make(chan struct{}), due: clock.Now().Add(deadline)}
+			ctx, cancel := context.WithCancelCause(context.WithValue(ctx, ackKey{}, a))
+			defer cancel(nil)
+
+			go a.watch(cancel)
+			err := next(ctx, data)
+			if a.acknowledge() {
+				return err
+			}
+			return ErrAckTimeout
+		}
+	}
+}
+
+// KeepAlive extends the acknowledgement deadline of the notification whose
+// Handler was given ctx to a whole AckDeadline from now. It reports false if
+// the Handler isn't in ack mode or its deadline has already passed
+func KeepAlive(ctx context.Context) bool {
+	a, ok := ctx.Value(ackKey{}).(*ack)
+	if !ok {
+		return false
+	}
+	a.Lock()
+	defer a.Unlock()
+
+	if a.acked || a.expired {
+		return false
+	}
+	a.due = a.clock.Now().Add(a.deadline)
+	return true
+}
+
+// Wait out the deadline, however many times it is pushed out, unless the
+// Handler returns first
+func (a *ack) watch(cancel context.CancelCauseFunc) {
+	wait := a.deadline
+	for {
+		timer := a.clock.NewTimer(wait)
+		select {
+		case <-a.done:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		a.Lock()
+		if a.acked {
+			a.Unlock()
+			return
+		}
+		if wait = a.due.Sub(a.clock.Now()); wait <= 0 {
+			a.expired = true
+		}
+		a.Unlock()
+
+		if wait <= 0 {
+			cancel(ErrAckTimeout)
+			return
+		}
+	}
+}
+
+// Record that the Handler returned, reporting whether it did in time
+func (a *ack) acknowledge() bool {
+	a.Lock()
+	defer a.Unlock()
+
+	if !a.expired {
+		a.acked = true
+		close(a.done)
+	}
+	return a.acked
+}
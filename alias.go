@@ -0,0 +1,240 @@
+package notify
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrAliasCycle = errors.New("Alias would form a cycle")
+
+// WithDeprecationHook calls hook every time an alias is used in place of the
+// event it names, ie: to log the producers and consumers still to be migrated
+// after a rename. hook runs on the goroutine using the alias
+func WithDeprecationHook(hook func(old string, event string)) Option {
+	return func(notifier *Notifier) {
+		notifier.onDeprecated = hook
+	}
+}
+
+// Alias makes old another name for event, so posts to either name reach the
+// observers of both. Observers already started on old are moved to event, as
+// are any aliases of old, its pending notifications and its sticky one unless
+// event's is newer, and from then on observers and posts using old are handled
+// as if they used event. Old's observers count as stopped for its lifecycle
+// hooks (see OnLastUnsubscribe). If event has never been posted to it takes
+// over the history of old, so Watch resumes from tokens issued for old;
+// otherwise those tokens have expired. Rate limits (see WithRateLimit), topic
+// concurrency (see WithTopicConcurrency) and samplers (see StartSampling) of
+// old apply to event unless it has its own, the first alias in name order
+// winning when several have one
+func (notifier *Notifier) Alias(old string, event string) error {
+	notifier.Lock()
+	defer notifier.Unlock()
+
+	event = notifier.canonical(event)
+	if event == old {
+		return ErrAliasCycle
+	}
+
+	aliases := make(map[string]string)
+	var retargeted []string
+	if current := notifier.aliases.Load(); current != nil {
+		for alias, target := range *current {
+			if target == old {
+				target = event
+				retargeted = append(retargeted, alias)
+			}
+			aliases[alias] = target
+		}
+	}
+	aliases[old] = event
+	stickyAliases := make(map[string]bool)
+	for alias, target := range aliases {
+		if notifier.stickyEvents[alias] {
+			stickyAliases[target] = true
+		}
+	}
+	notifier.aliases.Store(&aliases)
+	notifier.stickyAliases.Store(&stickyAliases)
+	notifier.aliasConfig.Store(notifier.configureAliases(aliases))
+	notifier.mergeSampler(old, event)
+
+	adopted := notifier.mergeEvent(old, event)
+	if notifier.resumable == nil {
+		notifier.resumable = make(map[string]bool)
+	}
+	if adopted {
+		notifier.resumable[old] = true
+	} else {
+		delete(notifier.resumable, old)
+		for _, alias := range retargeted {
+			delete(notifier.resumable, alias)
+		}
+	}
+	return nil
+}
+
+// The event named by event, which is itself unless it is an alias
+func (notifier *Notifier) canonical(event string) string {
+	if aliases := notifier.aliases.Load(); aliases != nil {
+		if target, ok := (*aliases)[event]; ok {
+			return target
+		}
+	}
+	return event
+}
+
+// Configuration of events given through their aliases only
+type aliasedConfig struct {
+	rateLimits map[string]*rateLimit
+	topics     map[string]*topicLock
+}
+
+// Collect the rate limits and topic concurrency of aliases for the events
+// they name that have none of their own
+func (notifier *Notifier) configureAliases(aliases map[string]string) *aliasedConfig {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	config := &aliasedConfig{rateLimits: make(map[string]*rateLimit), topics: make(map[string]*topicLock)}
+	for _, alias := range names {
+		target := aliases[alias]
+		if limit, ok := notifier.rateLimits[alias]; ok {
+			if _, own := notifier.rateLimits[target]; !own {
+				if _, taken := config.rateLimits[target]; !taken {
+					config.rateLimits[target] = limit
+				}
+			}
+		}
+		if lock, ok := notifier.topics[alias]; ok {
+			if _, own := notifier.topics[target]; !own {
+				if _, taken := config.topics[target]; !taken {
+					config.topics[target] = lock
+				}
+			}
+		}
+	}
+	return config
+}
+
+// The rate limit of event, configured for it or one of its aliases
+func (notifier *Notifier) rateLimit(event string) (*rateLimit, bool) {
+	if limit, ok := notifier.rateLimits[event]; ok {
+		return limit, true
+	}
+	if config := notifier.aliasConfig.Load(); config != nil {
+		limit, ok := config.rateLimits[event]
+		return limit, ok
+	}
+	return nil, false
+}
+
+// The topic lock of event, configured for it or one of its aliases
+func (notifier *Notifier) topicLock(event string) (*topicLock, bool) {
+	if lock, ok := notifier.topics[event]; ok {
+		return lock, true
+	}
+	if config := notifier.aliasConfig.Load(); config != nil {
+		lock, ok := config.topics[event]
+		return lock, ok
+	}
+	return nil, false
+}
+
+// Like canonical, reporting the use of an alias to the deprecation hook
+func (notifier *Notifier) resolve(event string) string {
+	target := notifier.canonical(event)
+	if target != event && notifier.onDeprecated != nil {
+		notifier.onDeprecated(event, target)
+	}
+	return target
+}
+
+// Move the observers, history, pending and sticky notifications of old to
+// event, returning whether event took over the sequence and history of old.
+// Must be called with the notifier locked
+func (notifier *Notifier) mergeEvent(old string, event string) bool {
+	from, to := notifier.shard(old), notifier.shard(event)
+	from.Lock()
+	if to != from {
+		to.Lock()
+	}
+
+	adopted := false
+	if entry, ok := from.events[old]; ok {
+		delete(from.events, old)
+		notifier.stopTicker(old)
+		moved := entry.observers()
+		if len(moved) > 0 {
+			notifier.observed(old, false)
+		}
+
+		var existing []*subscriber
+		target, ok := to.events[event]
+		if ok {
+			existing = target.observers()
+			notifier.eventCount.Add(-1)
+		}
+		if adopted = !ok || target.posted() == 0; adopted {
+			entry.setObservers(append(existing[:len(existing):len(existing)], moved...))
+			to.events[event] = entry
+		} else {
+			target.setObservers(append(existing[:len(existing):len(existing)], moved...))
+			entry.release()
+		}
+		if len(moved) > 0 {
+			notifier.startTicker(event)
+			if len(existing) == 0 {
+				notifier.observed(event, true)
+			}
+		}
+	}
+
+	if to != from {
+		to.Unlock()
+	}
+	from.Unlock()
+
+	notifier.mergeSticky(old, event)
+
+	notifier.pendingLock.Lock()
+	defer notifier.pendingLock.Unlock()
+
+	if pending, ok := notifier.pending[old]; ok {
+		delete(notifier.pending, old)
+		notifier.pending[event] = append(notifier.pending[event], pending...)
+	}
+	return adopted
+}
+
+// Keep the newer of the sticky notifications of old and event under event
+func (notifier *Notifier) mergeSticky(old string, event string) {
+	notifier.stickyLock.Lock()
+	defer notifier.stickyLock.Unlock()
+
+	value := notifier.sticky[old]
+	if value == nil {
+		return
+	}
+	delete(notifier.sticky, old)
+	kept := notifier.sticky[event]
+	if kept != nil && !kept.rec.posted.Before(value.rec.posted) {
+		notifier.memory.charge(-value.size)
+		return
+	}
+	if kept != nil {
+		notifier.memory.charge(-kept.size)
+	}
+	notifier.sticky[event] = value
+}
+
+// The number of notifications posted to the event
+func (entry *eventEntry) posted() uint64 {
+	entry.Lock()
+	defer entry.Unlock()
+
+	return entry.seq
+}
@@ -0,0 +1,241 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+func TestAliasMovesObservers(t *testing.T) {
+	deprecated := make(chan string, 1)
+	notifier := notify.NewNotifier(notify.WithDeprecationHook(func(old string, event string) {
+		deprecated <- old + "->" + event
+	}))
+	defer notifier.Close()
+
+	legacy, orders := make(chan interface{}, 1), make(chan interface{}, 1)
+	notifier.Start("legacy", legacy)
+	notifier.Start("orders", orders)
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Post("legacy", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-legacy; got != 1 {
+		t.Fatalf("moved observer got %v, want 1", got)
+	}
+	if got := <-orders; got != 1 {
+		t.Fatalf("observer of the event got %v, want 1", got)
+	}
+	if got := <-deprecated; got != "legacy->orders" {
+		t.Fatalf("deprecation hook called with %s, want legacy->orders", got)
+	}
+}
+
+func TestAliasCycle(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Alias("orders", "legacy"); err != notify.ErrAliasCycle {
+		t.Fatalf("Alias returned %v, want ErrAliasCycle", err)
+	}
+}
+
+func TestAliasAdoptsHistory(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithHistory(10))
+	defer notifier.Close()
+	notifier.Start("legacy", make(chan interface{}, 10))
+
+	stream, err := notifier.Watch("legacy", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postAll(notifier, "legacy", 1, 2)
+	token := nextChange(t, stream).Token
+	nextChange(t, stream)
+	stream.Close()
+
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := notifier.Watch("orders", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	if got := nextChange(t, resumed).Data; got != 2 {
+		t.Fatalf("resumed with %v, want 2", got)
+	}
+}
+
+func TestAliasExpiresTokensOfPostedEvent(t *testing.T) {
+	notifier := historyNotifier(t, 10)
+	notifier.Start("legacy", make(chan interface{}, 10))
+
+	stream, err := notifier.Watch("legacy", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postAll(notifier, "legacy", 1)
+	token := nextChange(t, stream).Token
+	stream.Close()
+	if err := notifier.Post("orders", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notifier.Watch("orders", token); err != notify.ErrResumeTokenExpired {
+		t.Fatalf("Watch returned %v, want ErrResumeTokenExpired", err)
+	}
+}
+
+func TestAliasKeepsNewerSticky(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithSticky("legacy", "config"))
+	defer notifier.Close()
+
+	notifier.Post("config", "old")
+	time.Sleep(time.Millisecond)
+	notifier.Post("legacy", "new")
+	if err := notifier.Alias("legacy", "config"); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := notifier.StickyValue("config"); !ok || value != "new" {
+		t.Fatalf("sticky value %v, %v, want the newer one", value, ok)
+	}
+	if value, ok := notifier.StickyValue("legacy"); !ok || value != "new" {
+		t.Fatalf("sticky value through the alias %v, %v, want new", value, ok)
+	}
+}
+
+func TestAliasLifecycleHooks(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	stopped, started := make(chan string, 1), make(chan string, 1)
+	notifier.OnLastUnsubscribe("legacy", func(event string) { stopped <- event })
+	notifier.OnFirstSubscriber("orders", func(event string) { started <- event })
+	notifier.Start("legacy", make(chan interface{}, 1))
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hook := range []struct {
+		calls chan string
+		want  string
+	}{{stopped, "legacy"}, {started, "orders"}} {
+		select {
+		case event := <-hook.calls:
+			if event != hook.want {
+				t.Fatalf("hook called for %s, want %s", event, hook.want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no hook called for %s", hook.want)
+		}
+	}
+}
+
+func TestAliasRateLimit(t *testing.T) {
+	notifier := notify.NewNotifier(
+		notify.WithRateLimit("legacy", 0, 1, notify.RateLimitError),
+	)
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 2))
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Post("orders", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Post("orders", 2); !errors.Is(err, notify.ErrRateLimited) {
+		t.Fatalf("post over the alias's limit returned %v, want ErrRateLimited", err)
+	}
+}
+
+func TestAliasRateLimitOwnWins(t *testing.T) {
+	notifier := notify.NewNotifier(
+		notify.WithRateLimit("legacy", 0, 1, notify.RateLimitError),
+		notify.WithRateLimit("orders", 0, 2, notify.RateLimitError),
+	)
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 3))
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := notifier.Post("legacy", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := notifier.Post("legacy", 2); !errors.Is(err, notify.ErrRateLimited) {
+		t.Fatalf("post over the event's limit returned %v, want ErrRateLimited", err)
+	}
+}
+
+func TestAliasTopicConcurrency(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithTopicConcurrency("legacy", notify.TopicSerial, nil))
+	defer notifier.Close()
+
+	var running, most atomic.Int32
+	var handled sync.WaitGroup
+	handler := func(ctx context.Context, data interface{}) error {
+		defer handled.Done()
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}
+	notifier.StartFunc("orders", handler)
+	notifier.StartFunc("orders", handler)
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	handled.Add(4)
+	notifier.Post("orders", 1)
+	notifier.Post("orders", 2)
+	handled.Wait()
+	if got := most.Load(); got != 1 {
+		t.Fatalf("%d handlers ran at once, want the alias's serial topic", got)
+	}
+}
+
+func TestAliasMovesSampler(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 1))
+
+	if err := notifier.StartSampling("legacy", notify.SampleConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Alias("legacy", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	notifier.Post("orders", 1)
+	samples, ok := notifier.Samples("orders")
+	if !ok || len(samples) != 1 {
+		t.Fatalf("sampled %v, %v, want the post to the event", samples, ok)
+	}
+	if _, ok := notifier.StopSampling("legacy"); !ok {
+		t.Fatal("sampler not stopped through the alias")
+	}
+}
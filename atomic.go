@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrSubscriberLimit = errors.New("Event subscriber limit reached")
+
+// WithSubscriberLimit caps the number of observers of each event. Starting
+// more fails with ErrSubscriberLimit (see Subscription.Err)
+func WithSubscriberLimit(limit int) Option {
+	return func(notifier *Notifier) {
+		notifier.subscriberLimit = limit
+	}
+}
+
+// Whether adding observers to event would exceed the subscriber limit. Must be
+// called with the event's shard locked
+func (notifier *Notifier) full(event string, adding int) bool {
+	if notifier.subscriberLimit <= 0 {
+		return false
+	}
+	observers := 0
+	if entry, ok := notifier.shard(event).events[event]; ok {
+		observers = len(entry.observers())
+	}
+	return observers+adding > notifier.subscriberLimit
+}
+
+// Subscriptions is a set of observers started together
+type Subscriptions []*Subscription
+
+// Unsubscribe stops every observer, returning the errors of those that
+// couldn't be stopped
+func (subscriptions Subscriptions) Unsubscribe() error {
+	var errs []error
+	for _, subscription := range subscriptions {
+		if err := subscription.Unsubscribe(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops every observer
+func (subscriptions Subscriptions) Close() error {
+	for _, subscription := range subscriptions {
+		subscription.Close()
+	}
+	return nil
+}
+
+// StartAtomic starts observing every event on its output channel, or none of
+// them: if any channel is nil or an event would exceed the subscriber or event
+// limit (see WithSubscriberLimit and WithMaxEvents) nothing is started, the
+// channels given WithChannelOwnership are closed and the error says why. No
+// other observer can start or stop on the events in between, so components
+// are never left half subscribed. The Subscriptions are sorted by event
+func (notifier *Notifier) StartAtomic(channels map[string]chan interface{}, options ...SubscribeOption) (Subscriptions, error) {
+	subs := make(map[string]*subscriber, len(channels))
+	events := make([]string, 0, len(channels))
+	for event, outputChan := range channels {
+		sub := &subscriber{outputChan: outputChan}
+		for _, option := range options {
+			option(sub)
+		}
+		subs[event] = sub
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	fail := func(err error) (Subscriptions, error) {
+		closeOwned(subs)
+		return nil, err
+	}
+
+	if notifier == nil {
+		return fail(ErrNilNotifier)
+	}
+	for _, event := range events {
+		if channels[event] == nil {
+			return fail(fmt.Errorf("%w: %q", ErrNilChannel, event))
+		}
+	}
+
+	notifier.RLock()
+	defer notifier.RUnlock()
+
+	// Shards are locked in order so concurrent calls can't deadlock
+	resolved := make([]string, len(events))
+	adding := make(map[string]int, len(events))
+	var locked [eventShards]bool
+	for i, event := range events {
+		resolved[i] = notifier.resolve(event)
+		adding[resolved[i]]++
+		locked[shardIndex(resolved[i])] = true
+	}
+	for i := range notifier.shards {
+		if locked[i] {
+			notifier.shards[i].Lock()
+		}
+	}
+	defer func() {
+		for i := range notifier.shards {
+			if locked[i] {
+				notifier.shards[i].Unlock()
+			}
+		}
+	}()
+
+	created := 0
+	for event, count := range adding {
+		if notifier.full(event, count) {
+			return fail(fmt.Errorf("%w: %q", ErrSubscriberLimit, event))
+		}
+		if _, ok := notifier.shard(event).events[event]; !ok {
+			created++
+		}
+	}
+	if !notifier.reserveEvents(created) {
+		return fail(ErrEventLimit)
+	}
+
+	subscriptions := make(Subscriptions, 0, len(events))
+	for i, event := range events {
+		sub := subs[event]
+		notifier.start(resolved[i], sub)
+		subscriptions = append(subscriptions, &Subscription{notifier: notifier, event: resolved[i], sub: sub})
+	}
+	return subscriptions, nil
+}
+
+// Close the output channels of subs given WithChannelOwnership, each once
+func closeOwned(subs map[string]*subscriber) {
+	closed := make(map[chan interface{}]bool)
+	for _, sub := range subs {
+		if sub.owned && sub.outputChan != nil && !closed[sub.outputChan] {
+			closed[sub.outputChan] = true
+			close(sub.outputChan)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package notify_test
+
+import (
+	"errors"
+	"testing"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+func TestStartAtomic(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	orders, payments := make(chan interface{}, 1), make(chan interface{}, 1)
+	subscriptions, err := notifier.StartAtomic(map[string]chan interface{}{"payments": payments, "orders": orders})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subscriptions) != 2 || subscriptions[0].Event() != "orders" || subscriptions[1].Event() != "payments" {
+		t.Fatalf("started %v, want orders and payments in order", subscriptions)
+	}
+	notifier.Post("payments", 1)
+	if got := <-payments; got != 1 {
+		t.Fatalf("observer got %v, want 1", got)
+	}
+}
+
+func TestStartAtomicFailureClosesOwnedChannels(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		options []notify.Option
+		nilChan bool
+		err     error
+	}{
+		{name: "nil channel", nilChan: true, err: notify.ErrNilChannel},
+		{name: "subscriber limit", options: []notify.Option{notify.WithSubscriberLimit(1)}, err: notify.ErrSubscriberLimit},
+		{name: "event limit", options: []notify.Option{notify.WithMaxEvents(1)}, err: notify.ErrEventLimit},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			notifier := notify.NewNotifier(test.options...)
+			defer notifier.Close()
+			notifier.Start("orders", make(chan interface{}, 1))
+
+			orders, payments := make(chan interface{}, 1), make(chan interface{}, 1)
+			channels := map[string]chan interface{}{"orders": orders, "payments": payments}
+			if test.nilChan {
+				channels["refunds"] = nil
+			}
+			_, err := notifier.StartAtomic(channels, notify.WithChannelOwnership())
+			if !errors.Is(err, test.err) {
+				t.Fatalf("StartAtomic returned %v, want %v", err, test.err)
+			}
+			for event, outputChan := range map[string]chan interface{}{"orders": orders, "payments": payments} {
+				select {
+				case _, ok := <-outputChan:
+					if ok {
+						t.Fatalf("%s observer got a notification", event)
+					}
+				default:
+					t.Fatalf("owned %s channel left open", event)
+				}
+			}
+		})
+	}
+}
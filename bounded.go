@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+var (
+	ErrEventLimit = errors.New("Event limit reached")
+	ErrUnbounded  = errors.New("Unbounded notifier configuration")
+	ErrOverBudget = errors.New("Notification exceeds the memory budget")
+)
+
+// WithMaxEvents caps the number of started events. Starting observers of any
+// further event fails with ErrEventLimit (see Subscription.Err) until some are
+// removed by StopAll or Prune
+func WithMaxEvents(limit int) Option {
+	return func(notifier *Notifier) {
+		notifier.maxEvents = limit
+	}
+}
+
+// WithMaxMemory bounds the notifications a notifier retains to about bytes,
+// as measured by its sizer (see WithSizer): the histories of its events (see
+// WithHistory), the notifications of sticky events (see WithSticky) and those
+// waiting to be mirrored (see WithMirror). With WithMaxEvents each history may
+// hold an equal share of bytes, without it any history may hold all of them.
+// Once the history of an event outgrows its share, or everything retained
+// outgrows bytes, the oldest notifications of the event are discarded as it
+// is posted to, even if the history holds fewer than its size. Sticky
+// notifications larger than a share are refused with ErrOverBudget, and
+// notifications that don't fit are mirrored no more than they'd be with a
+// full queue. Stats reports what is retained
+func WithMaxMemory(bytes int) Option {
+	return func(notifier *Notifier) {
+		notifier.maxMemory = bytes
+	}
+}
+
+// WithSizer measures notifications for WithMaxMemory, ApproxSize by default
+func WithSizer(size func(data interface{}) int) Option {
+	return func(notifier *Notifier) {
+		notifier.sizer = size
+	}
+}
+
+// NewBoundedNotifier creates a notifier for memory constrained deployments
+// whose memory doesn't grow with its load. Configurations that could grow
+// without bound are refused with ErrUnbounded: every notifier needs a memory
+// budget (WithMaxMemory), an event limit (WithMaxEvents) and a subscriber
+// limit (WithSubscriberLimit), and can't buffer posts to events without
+// observers (NoSubscribersBuffer) since the names of those aren't limited.
+// Only the notifier's own memory is checked: bridge links connected to it
+// hold up to their peer limit of peers (see bridge.WithPeerLimit) and the
+// buffers of their watches outside the memory budget
+func NewBoundedNotifier(options ...Option) (*Notifier, error) {
+	notifier := NewNotifier(options...)
+
+	var err error
+	switch {
+	case notifier.maxMemory <= 0:
+		err = fmt.Errorf("%w: no memory budget (see WithMaxMemory)", ErrUnbounded)
+	case notifier.maxEvents <= 0:
+		err = fmt.Errorf("%w: no event limit (see WithMaxEvents)", ErrUnbounded)
+	case notifier.subscriberLimit <= 0:
+		err = fmt.Errorf("%w: no subscriber limit (see WithSubscriberLimit)", ErrUnbounded)
+	case notifier.noSubscribers == NoSubscribersBuffer:
+		err = fmt.Errorf("%w: posts to events without observers are buffered", ErrUnbounded)
+	}
+	if err != nil {
+		notifier.Close()
+		return nil, err
+	}
+	return notifier, nil
+}
+
+// The memory WithMaxMemory lets a notifier retain
+type memoryBudget struct {
+	limit int          // in all
+	share int          // for each event's history
+	used  atomic.Int64 // by histories, sticky notifications and the mirror queue
+	size  func(data interface{}) int
+}
+
+func newMemoryBudget(limit int, share int, size func(data interface{}) int) *memoryBudget {
+	if size == nil {
+		size = ApproxSize
+	}
+	return &memoryBudget{limit: limit, share: share, size: size}
+}
+
+// Count bytes as retained, or no longer retained if negative. The budget may
+// be nil
+func (memory *memoryBudget) charge(bytes int) {
+	if memory != nil {
+		memory.used.Add(int64(bytes))
+	}
+}
+
+// The bytes retained, zero for a nil budget
+func (memory *memoryBudget) retained() uint64 {
+	if memory == nil {
+		return 0
+	}
+	return uint64(max(memory.used.Load(), 0))
+}
+
+// Whether bytes more can be retained. Always true of a nil budget
+func (memory *memoryBudget) fits(bytes int) bool {
+	return memory == nil || memory.used.Load()+int64(bytes) <= int64(memory.limit)
+}
+
+// Discard the oldest records until the history fits the event's share of the
+// memory budget and everything retained fits the budget. Must be called with
+// the entry locked
+func (entry *eventEntry) trim() {
+	if entry.memory == nil {
+		return
+	}
+	count, total, over := 0, entry.retained, entry.memory.used.Load()-int64(entry.memory.limit)
+	for ; count < len(entry.history) && (total > entry.memory.share || over > 0); count++ {
+		total -= entry.history[count].size
+		over -= int64(entry.history[count].size)
+	}
+	entry.discard(count)
+}
+
+// Give the memory held by the history back to the budget once the entry is
+// removed. Posts still holding the entry no longer count what they record
+func (entry *eventEntry) release() {
+	entry.Lock()
+	defer entry.Unlock()
+
+	entry.discard(len(entry.history))
+	entry.memory = nil
+}
+
+// Refuse a sticky notification larger than an event's share of the memory
+// budget
+func (notifier *Notifier) fitSticky(event string, data interface{}) error {
+	if notifier.memory == nil || !notifier.isSticky(event) {
+		return nil
+	}
+	if notifier.memory.size(data) > notifier.memory.share {
+		return fmt.Errorf("%w: %q", ErrOverBudget, event)
+	}
+	return nil
+}
+
+// Check that observers can be added to event without exceeding the subscriber
+// or event limit, counting event if it is new. Must be called with the
+// event's shard locked and followed by start
+func (notifier *Notifier) admit(event string, adding int) error {
+	if notifier.full(event, adding) {
+		return ErrSubscriberLimit
+	}
+	if _, ok := notifier.shard(event).events[event]; !ok && !notifier.reserveEvents(1) {
+		return ErrEventLimit
+	}
+	return nil
+}
+
+// Count events about to be started, unless that would exceed the event limit
+func (notifier *Notifier) reserveEvents(count int) bool {
+	if count == 0 {
+		return true
+	}
+	for {
+		current := notifier.eventCount.Load()
+		if notifier.maxEvents > 0 && current+int64(count) > int64(notifier.maxEvents) {
+			return false
+		}
+		if notifier.eventCount.CompareAndSwap(current, current+int64(count)) {
+			return true
+		}
+	}
+}
+
+// ApproxSize estimates the bytes held by data: the size of its value plus
+// whatever its strings, slices, maps, pointers and interfaces refer to.
+// Memory reached more than once, ie: through cycles, is counted once
+func ApproxSize(data interface{}) int {
+	if data == nil {
+		return 0
+	}
+	value := reflect.ValueOf(data)
+	return int(value.Type().Size()) + referencedSize(value, make(map[uintptr]bool))
+}
+
+// The bytes value refers to beyond its own size
+func referencedSize(value reflect.Value, seen map[uintptr]bool) int {
+	switch value.Kind() {
+	case reflect.String:
+		return value.Len()
+	case reflect.Pointer:
+		if value.IsNil() || seen[value.Pointer()] {
+			return 0
+		}
+		seen[value.Pointer()] = true
+		return int(value.Type().Elem().Size()) + referencedSize(value.Elem(), seen)
+	case reflect.Interface:
+		if value.IsNil() {
+			return 0
+		}
+		return int(value.Elem().Type().Size()) + referencedSize(value.Elem(), seen)
+	case reflect.Slice:
+		if value.IsNil() || seen[value.Pointer()] {
+			return 0
+		}
+		seen[value.Pointer()] = true
+		size := value.Cap() * int(value.Type().Elem().Size())
+		for i := 0; i < value.Len(); i++ {
+			size += referencedSize(value.Index(i), seen)
+		}
+		return size
+	case reflect.Array:
+		size := 0
+		for i := 0; i < value.Len(); i++ {
+			size += referencedSize(value.Index(i), seen)
+		}
+		return size
+	case reflect.Map:
+		if value.IsNil() || seen[value.Pointer()] {
+			return 0
+		}
+		seen[value.Pointer()] = true
+		size := 0
+		entry := int(value.Type().Key().Size() + value.Type().Elem().Size())
+		for iter := value.MapRange(); iter.Next(); {
+			size += entry + referencedSize(iter.Key(), seen) + referencedSize(iter.Value(), seen)
+		}
+		return size
+	case reflect.Struct:
+		size := 0
+		for i := 0; i < value.NumField(); i++ {
+			size += referencedSize(value.Field(i), seen)
+		}
+		return size
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package notify_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// Notifications in these tests are ints measuring as many bytes
+func intSize(data interface{}) int {
+	return data.(int)
+}
+
+func TestNewBoundedNotifierRefusesUnbounded(t *testing.T) {
+	bounded := []notify.Option{notify.WithMaxMemory(1000), notify.WithMaxEvents(10), notify.WithSubscriberLimit(10)}
+	for _, test := range []struct {
+		name    string
+		options []notify.Option
+	}{
+		{name: "no memory budget", options: bounded[1:]},
+		{name: "no event limit", options: []notify.Option{bounded[0], bounded[2]}},
+		{name: "no subscriber limit", options: bounded[:2]},
+		{name: "buffered posts", options: append(bounded[:3:3], notify.WithNoSubscribers(notify.NoSubscribersBuffer))},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			notifier, err := notify.NewBoundedNotifier(test.options...)
+			if !errors.Is(err, notify.ErrUnbounded) || notifier != nil {
+				t.Fatalf("NewBoundedNotifier returned %v, %v, want ErrUnbounded", notifier, err)
+			}
+		})
+	}
+
+	notifier, err := notify.NewBoundedNotifier(bounded...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier.Close()
+}
+
+func TestMaxMemoryTrimsHistory(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxMemory(100), notify.WithSizer(intSize), notify.WithHistory(100))
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 100))
+
+	stream, _ := notifier.Watch("orders", "")
+	postAll(notifier, "orders", 10)
+	token := nextChange(t, stream).Token
+	stream.Close()
+	for i := 0; i < 11; i++ {
+		notifier.Post("orders", 10)
+	}
+	if retained := notifier.Stats().Retained; retained != 100 {
+		t.Fatalf("retained %d bytes, want the 100 of the budget", retained)
+	}
+	// The history kept fewer than its size to fit the budget
+	if _, err := notifier.Watch("orders", token); err != notify.ErrResumeTokenExpired {
+		t.Fatalf("resuming from a trimmed notification returned %v, want ErrResumeTokenExpired", err)
+	}
+
+	notifier.Post("orders", 60)
+	if retained := notifier.Stats().Retained; retained != 100 {
+		t.Fatalf("retained %d bytes after a large post, want 100", retained)
+	}
+}
+
+func TestMaxMemorySharedByEvents(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxMemory(100), notify.WithMaxEvents(4), notify.WithSizer(intSize), notify.WithHistory(100))
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 100))
+	notifier.Start("payments", make(chan interface{}, 100))
+
+	for i := 0; i < 10; i++ {
+		notifier.Post("orders", 10)
+	}
+	if retained := notifier.Stats().Retained; retained != 20 {
+		t.Fatalf("retained %d bytes, want the 25 byte share of orders rounded down to whole notifications", retained)
+	}
+	notifier.Post("payments", 5)
+	if retained := notifier.Stats().Retained; retained != 25 {
+		t.Fatalf("retained %d bytes, want 25", retained)
+	}
+}
+
+func TestMaxMemoryReleased(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxMemory(100), notify.WithSizer(intSize), notify.WithHistory(10))
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 100))
+	notifier.Post("orders", 30)
+	notifier.Post("orders", 30)
+
+	notifier.StopAll("orders")
+	if retained := notifier.Stats().Retained; retained != 0 {
+		t.Fatalf("retained %d bytes once the event was removed, want 0", retained)
+	}
+}
+
+func TestMaxMemorySticky(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxMemory(100), notify.WithMaxEvents(2), notify.WithSizer(intSize), notify.WithSticky("config"))
+	defer notifier.Close()
+
+	if err := notifier.Post("config", 60); !errors.Is(err, notify.ErrOverBudget) {
+		t.Fatalf("sticky post over the share returned %v, want ErrOverBudget", err)
+	}
+	notifier.Post("config", 40)
+	notifier.Post("config", 30)
+	if retained := notifier.Stats().Retained; retained != 30 {
+		t.Fatalf("retained %d bytes, want only the latest sticky value", retained)
+	}
+}
+
+func TestMaxMemoryMirror(t *testing.T) {
+	recorder := notify.NewNotifier()
+	defer recorder.Close()
+	mirrored := make(chan interface{})
+	recorder.Start("orders", mirrored)
+
+	notifier := notify.NewNotifier(notify.WithMaxMemory(100), notify.WithSizer(intSize),
+		notify.WithMirror(recorder, notify.MirrorConfig{}))
+	defer notifier.Close()
+
+	// The first is taken off the queue while the recorder holds it up
+	notifier.Post("orders", 10)
+	deadline := time.Now().Add(time.Second)
+	for notifier.Stats().Retained != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("mirrored notification still retained once taken off the queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	notifier.Post("orders", 70)
+	notifier.Post("orders", 40)
+	stats := notifier.Stats()
+	if stats.Retained != 70 || stats.MirrorDropped != 1 {
+		t.Fatalf("retained %d bytes and dropped %d, want 70 queued and the one over budget dropped", stats.Retained, stats.MirrorDropped)
+	}
+
+	for _, want := range []int{10, 70} {
+		if got := <-mirrored; got != want {
+			t.Fatalf("mirrored %v, want %d", got, want)
+		}
+	}
+	deadline = time.Now().Add(time.Second)
+	for notifier.Stats().Retained != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("memory not released once mirrored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
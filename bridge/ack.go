@@ -0,0 +1,140 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var ErrUnacknowledged = errors.New("Bridged notification not acknowledged")
+
+// DefaultAckLimit is the number of forwarded notifications a link waits on
+// acknowledgements for at once. Notifications forwarded beyond it aren't
+// tracked
+const DefaultAckLimit = 1024
+
+// Ack frames start with this byte in place of a frame version, followed by
+// the length prefixed origin of the link acknowledging, that of the link the
+// notification came from and its sequence number
+const ackFrame = 0x83
+
+// WithAcks asks the peers that import a forwarded notification, and can
+// acknowledge it (see CapabilityAck), to do so once they have posted it to
+// their notifier. Notifications some of them haven't acknowledged within
+// timeout are reported to the error handler as ErrUnacknowledged. They aren't
+// sent again: every peer on the bus would get them twice
+func WithAcks(timeout time.Duration) Option {
+	return func(link *Link) {
+		link.ackTimeout = timeout
+	}
+}
+
+// A forwarded notification awaiting acknowledgements
+type pendingAck struct {
+	event   string
+	due     time.Time
+	waiting map[string]bool // peers yet to acknowledge
+}
+
+// The sequence number to ask the peers importing event to acknowledge its
+// next notification with, zero if none of them would or too many
+// notifications are awaiting acknowledgements already
+func (link *Link) expectAcks(event string) uint64 {
+	ackers := link.ackers(event)
+	if len(ackers) == 0 {
+		return 0
+	}
+
+	link.ackLock.Lock()
+	defer link.ackLock.Unlock()
+
+	if len(link.acks) >= DefaultAckLimit {
+		return 0
+	}
+	link.ackSeq++
+	link.acks[link.ackSeq] = &pendingAck{event: event, due: link.clock.Now().Add(link.ackTimeout), waiting: ackers}
+	return link.ackSeq
+}
+
+// Acknowledge a notification imported from origin
+func (link *Link) ack(origin string, seq uint64) {
+	frame := []byte{ackFrame}
+	frame = binary.AppendUvarint(frame, uint64(len(link.origin)))
+	frame = append(frame, link.origin...)
+	frame = binary.AppendUvarint(frame, uint64(len(origin)))
+	frame = append(frame, origin...)
+	frame = binary.AppendUvarint(frame, seq)
+	if err := link.publishControl(frame); err != nil {
+		link.onError(err)
+	}
+}
+
+// Record a peer's acknowledgement of a notification the link forwarded
+func (link *Link) receiveAck(frame []byte) {
+	acker, rest, err := readString(frame[1:])
+	if err == nil {
+		var origin string
+		if origin, rest, err = readString(rest); err == nil && origin != link.origin {
+			return
+		}
+	}
+	if err != nil {
+		link.reject("", "", frame, err)
+		return
+	}
+	seq, size := binary.Uvarint(rest)
+	if size <= 0 {
+		link.reject("", "", frame, ErrBadFrame)
+		return
+	}
+
+	link.ackLock.Lock()
+	defer link.ackLock.Unlock()
+
+	if pending, ok := link.acks[seq]; ok {
+		delete(pending.waiting, acker)
+		if len(pending.waiting) == 0 {
+			delete(link.acks, seq)
+		}
+	}
+}
+
+// Report the notifications whose acknowledgements are overdue, every half
+// ack timeout until the link is closed
+func (link *Link) expireAcks() {
+	ticker := link.clock.NewTicker(link.ackTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-link.stop:
+			return
+		case <-ticker.C():
+		}
+
+		now := link.clock.Now()
+		var overdue []error
+		link.ackLock.Lock()
+		for seq, pending := range link.acks {
+			if now.Before(pending.due) {
+				continue
+			}
+			peers := make([]string, 0, len(pending.waiting))
+			for peer := range pending.waiting {
+				peers = append(peers, peer)
+			}
+			sort.Strings(peers)
+			overdue = append(overdue, fmt.Errorf("%w: %q by peers %v", ErrUnacknowledged, pending.event, peers))
+			delete(link.acks, seq)
+		}
+		link.ackLock.Unlock()
+
+		for _, err := range overdue {
+			link.onError(err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//go:build !notifyminimal
+
+package bridge_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jesus-ramos/go-notify/bridge"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+// Connect a link exporting orders and asking for acks, timed by a fake clock,
+// and one importing them. Returns a channel receiving the ack frames once the
+// exporter has handled them
+func ackPair(t *testing.T, bus *memoryBus, errs errorLog) (*notifytest.FakeClock, func(data interface{}), <-chan interface{}, <-chan struct{}) {
+	t.Helper()
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	exporter, received := pair(t, bus, "orders",
+		[]bridge.Option{bridge.WithAcks(time.Minute), bridge.WithClock(clock), errs.handler()}, nil)
+
+	acks := make(chan struct{}, 16)
+	bus.client().Subscribe(func(frame []byte) {
+		if frame[0] == 0x83 {
+			acks <- struct{}{}
+		}
+	})
+	// The heartbeat and ack tickers
+	for clock.Timers() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	return clock, func(data interface{}) { exporter.Post("orders", data) }, received, acks
+}
+
+func TestLinkAcknowledged(t *testing.T) {
+	errs := make(errorLog, 16)
+	clock, post, received, acks := ackPair(t, &memoryBus{}, errs)
+
+	post("order 1")
+	receive(t, received)
+	select {
+	case <-acks:
+	case <-time.After(time.Second):
+		t.Fatal("imported notification not acknowledged")
+	}
+
+	clock.Advance(time.Minute)
+	clock.Advance(time.Minute)
+	select {
+	case err := <-errs:
+		t.Fatalf("reported %v for an acknowledged notification", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestLinkUnacknowledged(t *testing.T) {
+	bus := &memoryBus{}
+	errs := make(errorLog, 16)
+	clock, post, received, _ := ackPair(t, bus, errs)
+
+	// Lost on the way to the importer
+	bus.tamperNotifications(func([]byte) []byte { return nil })
+	post("order 1")
+	deadline := time.Now().Add(time.Second)
+	for {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, bridge.ErrUnacknowledged) {
+				t.Fatalf("reported %v, want ErrUnacknowledged", err)
+			}
+			nothing(t, received)
+			return
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("lost notification not reported")
+		}
+		clock.Advance(30 * time.Second)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLinkAcksOnlyWhenAsked(t *testing.T) {
+	bus := &memoryBus{}
+	exporter, received := pair(t, bus, "orders", nil, nil)
+	acks := make(chan struct{}, 16)
+	bus.client().Subscribe(func(frame []byte) {
+		if frame[0] == 0x83 {
+			acks <- struct{}{}
+		}
+	})
+
+	exporter.Post("orders", "order 1")
+	receive(t, received)
+	select {
+	case <-acks:
+		t.Fatal("notification acknowledged without WithAcks")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
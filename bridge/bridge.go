@@ -0,0 +1,414 @@
+//go:build !notifyminimal
+
+// Package bridge forwards notifications between the notifiers of different
+// processes over a message bus such as NATS or Redis Pub/Sub.
+//
+// Example:
+//
+//	b, err := bridge.DialNATS("localhost:4222", "notify")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	link, err := bridge.Connect(notifier, b,
+//		bridge.Export("user_created"),
+//		bridge.Import("user_*"))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer link.Close()
+package bridge
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+var (
+	ErrBridgeClosed      = errors.New("Bridge closed")
+	ErrAlreadySubscribed = errors.New("Bridge already subscribed")
+	ErrBadFrame          = errors.New("Malformed bridge frame")
+	ErrLinkIdle          = errors.New("Bridge link idle")
+)
+
+// Bridge carries opaque frames between processes. Every frame published by
+// one process is delivered to the other processes sharing the bus
+type Bridge interface {
+	// Publish sends a frame to remote peers
+	Publish(frame []byte) error
+	// Subscribe delivers frames published by peers to handler until the bridge
+	// is closed. It may only be called once
+	Subscribe(handler func(frame []byte)) error
+	Close() error
+}
+
+// Option configures a Link
+type Option func(*Link)
+
+// Export forwards the named local events to remote peers
+func Export(events ...string) Option {
+	return func(link *Link) {
+		link.exports = append(link.exports, events...)
+	}
+}
+
+// Import posts remote events matching any of the patterns (see path.Match) to
+// the local notifier
+func Import(patterns ...string) Option {
+	return func(link *Link) {
+		link.imports = append(link.imports, patterns...)
+	}
+}
+
+// WithCodec sets the codec used to serialize notifications (notify.JSONCodec
+// by default)
+func WithCodec(codec notify.Codec) Option {
+	return func(link *Link) {
+		link.codec = codec
+	}
+}
+
+// WithErrorHandler receives errors encountered while forwarding, which are
+// otherwise dropped
+func WithErrorHandler(handler func(err error)) Option {
+	return func(link *Link) {
+		link.onError = handler
+	}
+}
+
+// Quarantine posts frames that can't be imported, because they are malformed,
+// fail to decrypt or decode, or aren't validly signed, to the local event as
+// notify.Quarantined notifications. An empty event uses
+// notify.QuarantineEvent. Errors are still reported to the error handler
+func Quarantine(event string) Option {
+	return func(link *Link) {
+		link.quarantine = true
+		link.quarantineEvent = event
+	}
+}
+
+// WithIdleTimeout closes the link, and with it the bridge, once no frames have
+// been forwarded or received for timeout, reporting ErrLinkIdle to the error
+// handler. Use it for links to peers that may go away without closing their
+// connection
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(link *Link) {
+		link.idleTimeout = timeout
+	}
+}
+
+// WithClock makes the link tell time with clock, ie: the one given to its
+// notifier with notify.WithClock. It is used for peer expiry,
+// acknowledgement and idle timeouts
+func WithClock(clock notify.Clock) Option {
+	return func(link *Link) {
+		link.clock = clock
+	}
+}
+
+// Link connects a notifier to a bridge
+type Link struct {
+	notifier *notify.Notifier
+	bridge   Bridge
+	origin   string
+	codec    notify.Codec
+	exports  []string
+	imports  []string
+	keys     []topicKey
+	signer   Signer
+	compress bool
+	clock    notify.Clock
+	onError  func(err error)
+	err      error // from configuring options
+
+	quarantine      bool
+	quarantineEvent string
+
+	unregister func() // removes the link's self test
+
+	idleTimeout time.Duration
+	active      atomic.Int64 // UnixNano of the last frame forwarded or received
+	stop        chan struct{}
+
+	peers     map[string]Peer
+	peerLimit int
+	peerTTL   time.Duration
+	peerLock  sync.Mutex
+
+	ackTimeout time.Duration
+	acks       map[uint64]*pendingAck // see WithAcks
+	ackSeq     uint64
+	ackLock    sync.Mutex
+
+	discovery     bool
+	requests      map[uint64]*discovery // outstanding RemoteTopics
+	requestID     uint64
+	watches       []*RemoteWatch
+	watchesClosed bool
+	discoveryLock sync.Mutex
+
+	outputChans   map[string]chan interface{}
+	subscriptions []*notify.Subscription
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+}
+
+// Connect starts forwarding notifications between notifier and bridge. If an
+// exported event can't be observed (see notify.Subscription.Err) nothing is
+// left started and the error is returned. What the link holds isn't counted
+// against the memory budget of a bounded notifier (see
+// notify.NewBoundedNotifier)
+func Connect(notifier *notify.Notifier, bridge Bridge, options ...Option) (*Link, error) {
+	link := &Link{
+		notifier:    notifier,
+		bridge:      bridge,
+		origin:      newOrigin(),
+		codec:       notify.JSONCodec{},
+		clock:       notify.SystemClock,
+		onError:     func(error) {},
+		outputChans: make(map[string]chan interface{}),
+		peers:       make(map[string]Peer),
+		peerLimit:   DefaultPeerLimit,
+		peerTTL:     DefaultPeerTTL,
+		acks:        make(map[uint64]*pendingAck),
+		requests:    make(map[uint64]*discovery),
+		stop:        make(chan struct{}),
+	}
+	for _, option := range options {
+		option(link)
+	}
+	if link.err != nil {
+		return nil, link.err
+	}
+	for _, key := range link.keys {
+		for _, pattern := range key.patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, pattern := range link.imports {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, event := range link.exports {
+		outputChan := make(chan interface{})
+		subscription := notifier.Start(event, outputChan, notify.WithChannelOwnership(), notify.WithComponent("bridge link "+link.origin))
+		if err := subscription.Err(); err != nil {
+			link.stopExports()
+			return nil, err
+		}
+		link.outputChans[event] = outputChan
+		link.subscriptions = append(link.subscriptions, subscription)
+
+		link.wg.Add(1)
+		go link.forward(event, outputChan)
+	}
+	// Even links that only export listen to the bus, to learn what their
+	// peers can read and import
+	if err := bridge.Subscribe(link.receive); err != nil {
+		link.stopExports()
+		return nil, err
+	}
+	if link.idleTimeout > 0 {
+		link.touch()
+		go link.reapIdle()
+	}
+	if link.peerTTL > 0 {
+		go link.heartbeat()
+	}
+	if link.ackTimeout > 0 {
+		go link.expireAcks()
+	}
+	if err := link.hello(); err != nil {
+		link.onError(err)
+	}
+	link.unregister = notifier.RegisterSelfTest("bridge link "+link.origin, link.selfTest)
+
+	return link, nil
+}
+
+// Close stops forwarding and closes the bridge
+func (link *Link) Close() error {
+	var err error
+	link.closeOnce.Do(func() {
+		close(link.stop)
+		link.unregister()
+		link.stopExports()
+		link.closeWatches()
+		err = link.bridge.Close()
+	})
+	return err
+}
+
+// Stop observing the exported events and wait for what is being forwarded
+func (link *Link) stopExports() {
+	for _, subscription := range link.subscriptions {
+		subscription.Unsubscribe()
+	}
+	link.wg.Wait()
+}
+
+func (link *Link) forward(event string, outputChan chan interface{}) {
+	defer link.wg.Done()
+
+	for data := range outputChan {
+		// Frames only go out when a peer may want them, and in a form every
+		// peer reads
+		if !link.wanted(event) {
+			continue
+		}
+		version, shared := link.shared()
+		var seq uint64
+		if link.ackTimeout > 0 && version >= flaggedFrameVersion {
+			seq = link.expectAcks(event)
+		}
+		frame, err := link.newFrame(event, data, version, shared, seq)
+		if err != nil {
+			link.onError(err)
+			continue
+		}
+		if err := link.bridge.Publish(frame.encode()); err != nil {
+			link.onError(err)
+		}
+		link.touch()
+	}
+}
+
+func (link *Link) receive(frame []byte) {
+	link.touch()
+	if len(frame) > 0 {
+		switch frame[0] {
+		case signedControlFrame:
+			control, err := link.verifyControl(frame)
+			if err != nil {
+				link.reject("", "", frame, err)
+				return
+			}
+			link.receiveControl(control)
+			return
+		case helloFrame, discoverFrame, topicsFrame, ackFrame:
+			if link.signer != nil {
+				link.reject("", "", frame, ErrBadSignature)
+				return
+			}
+			link.receiveControl(frame)
+			return
+		}
+	}
+	f, err := decodeFrame(frame)
+	if err != nil {
+		link.reject("", "", frame, err)
+		return
+	}
+	origin, event := f.origin, f.event
+	imported := link.imported(event)
+	if origin == link.origin || (!imported && !link.watched(event)) {
+		return
+	}
+	data, err := link.readFrame(f)
+	if err != nil {
+		link.reject(origin, event, f.payload, err)
+		return
+	}
+	link.deliverWatches(origin, event, data)
+	if !imported {
+		return
+	}
+	// Don't hand the notification straight back to the bridge if the event is
+	// also exported
+	err = link.notifier.PostExcept(event, data, link.outputChans[event])
+	if err != nil && err != notify.ErrEventNotFound {
+		link.onError(err)
+	}
+	if f.flags&flagAck != 0 {
+		link.ack(origin, f.seq)
+	}
+}
+
+// Handle a hello or discovery frame, once its signature has been checked
+func (link *Link) receiveControl(frame []byte) {
+	switch frame[0] {
+	case helloFrame:
+		link.receiveHello(frame)
+	case discoverFrame:
+		if link.discovery {
+			link.receiveDiscover(frame)
+		}
+	case topicsFrame:
+		if link.discovery {
+			link.receiveTopics(frame)
+		}
+	case ackFrame:
+		link.receiveAck(frame)
+	default:
+		link.reject("", "", frame, ErrBadFrame)
+	}
+}
+
+// Record activity on the link for WithIdleTimeout
+func (link *Link) touch() {
+	link.active.Store(link.clock.Now().UnixNano())
+}
+
+// Close the link once it has been idle for its idle timeout
+func (link *Link) reapIdle() {
+	wait := link.idleTimeout
+	for {
+		timer := link.clock.NewTimer(wait)
+		select {
+		case <-link.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		idle := link.clock.Now().Sub(time.Unix(0, link.active.Load()))
+		if idle >= link.idleTimeout {
+			link.onError(ErrLinkIdle)
+			link.Close()
+			return
+		}
+		wait = link.idleTimeout - idle
+	}
+}
+
+// Report a frame that couldn't be imported, quarantining it if enabled
+func (link *Link) reject(origin string, event string, payload []byte, err error) {
+	link.onError(err)
+	if !link.quarantine {
+		return
+	}
+	rejected := notify.Quarantined{
+		Event:   event,
+		Source:  origin,
+		Payload: append([]byte(nil), payload...),
+		Err:     err,
+	}
+	if err := link.notifier.PostQuarantined(link.quarantineEvent, rejected); err != nil {
+		link.onError(err)
+	}
+}
+
+func (link *Link) imported(event string) bool {
+	for _, pattern := range link.imports {
+		if matched, _ := path.Match(pattern, event); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func newOrigin() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,200 @@
+//go:build !notifyminimal
+
+package bridge_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/bridge"
+)
+
+// memoryBus hands every frame published on it to every link subscribed,
+// including the publisher's, as a broker would. tamper, if set, may rewrite
+// or drop (by returning nil) frames on their way
+type memoryBus struct {
+	sync.Mutex
+	handlers []func(frame []byte)
+	tamper   func(frame []byte) []byte
+}
+
+// A Bridge to the bus
+func (bus *memoryBus) client() bridge.Bridge {
+	return &busClient{bus: bus}
+}
+
+// Rewrite or drop the notification frames published from now on, leaving
+// hello, discovery and ack frames alone
+func (bus *memoryBus) tamperNotifications(tamper func(frame []byte) []byte) {
+	bus.Lock()
+	defer bus.Unlock()
+
+	bus.tamper = func(frame []byte) []byte {
+		if frame[0] < 1 || frame[0] > bridge.ProtocolVersion {
+			return frame
+		}
+		return tamper(frame)
+	}
+}
+
+func (bus *memoryBus) subscribers() int {
+	bus.Lock()
+	defer bus.Unlock()
+
+	return len(bus.handlers)
+}
+
+type busClient struct {
+	bus *memoryBus
+}
+
+func (client *busClient) Publish(frame []byte) error {
+	bus := client.bus
+	bus.Lock()
+	handlers, tamper := append(([]func([]byte))(nil), bus.handlers...), bus.tamper
+	bus.Unlock()
+
+	frame = append([]byte(nil), frame...)
+	if tamper != nil {
+		if frame = tamper(frame); frame == nil {
+			return nil
+		}
+	}
+	for _, handler := range handlers {
+		handler(frame)
+	}
+	return nil
+}
+
+func (client *busClient) Subscribe(handler func(frame []byte)) error {
+	client.bus.Lock()
+	defer client.bus.Unlock()
+
+	client.bus.handlers = append(client.bus.handlers, handler)
+	return nil
+}
+
+func (client *busClient) Close() error {
+	return nil
+}
+
+// Errors reported by a link, for WithErrorHandler
+type errorLog chan error
+
+func (log errorLog) handler() bridge.Option {
+	return bridge.WithErrorHandler(func(err error) {
+		select {
+		case log <- err:
+		default:
+		}
+	})
+}
+
+// Wait for an error matching target
+func (log errorLog) expect(t *testing.T, target error) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case err := <-log:
+			if errors.Is(err, target) {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no %v reported", target)
+		}
+	}
+}
+
+func receive(t *testing.T, outputChan <-chan interface{}) interface{} {
+	t.Helper()
+	select {
+	case data := <-outputChan:
+		return data
+	case <-time.After(time.Second):
+		t.Fatal("nothing bridged")
+	}
+	return nil
+}
+
+func nothing(t *testing.T, outputChan <-chan interface{}) {
+	t.Helper()
+	select {
+	case data := <-outputChan:
+		t.Fatalf("bridged %v", data)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// Connect notifier to bus, closing the link once the test is over
+func connect(t *testing.T, notifier *notify.Notifier, bus *memoryBus, options ...bridge.Option) *bridge.Link {
+	t.Helper()
+	link, err := bridge.Connect(notifier, bus.client(), options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { link.Close() })
+	return link
+}
+
+func newNotifier(t *testing.T, options ...notify.Option) *notify.Notifier {
+	notifier := notify.NewNotifier(options...)
+	t.Cleanup(func() { notifier.Close() })
+	return notifier
+}
+
+// Connect an exporting and an importing notifier of event over bus
+func pair(t *testing.T, bus *memoryBus, event string, exportOptions []bridge.Option, importOptions []bridge.Option) (*notify.Notifier, <-chan interface{}) {
+	t.Helper()
+	exporter, importer := notify.NewNotifier(), notify.NewNotifier()
+	t.Cleanup(func() {
+		exporter.Close()
+		importer.Close()
+	})
+	received, _ := importer.StartOwned(event, 16)
+
+	importLink, err := bridge.Connect(importer, bus.client(), append(importOptions, bridge.Import(event))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { importLink.Close() })
+	exportLink, err := bridge.Connect(exporter, bus.client(), append(exportOptions, bridge.Export(event))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { exportLink.Close() })
+	return exporter, received
+}
+
+func TestLinkForwards(t *testing.T) {
+	exporter, received := pair(t, &memoryBus{}, "orders", nil, nil)
+
+	if err := exporter.Post("orders", "order 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, received); got != "order 1" {
+		t.Fatalf("imported %v, want order 1", got)
+	}
+}
+
+func TestConnectFailedExport(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxEvents(1))
+	defer notifier.Close()
+	bus := &memoryBus{}
+
+	_, err := bridge.Connect(notifier, bus.client(), bridge.Export("orders", "payments"))
+	if !errors.Is(err, notify.ErrEventLimit) {
+		t.Fatalf("Connect returned %v, want ErrEventLimit", err)
+	}
+	for _, snapshot := range notifier.Snapshot() {
+		if len(snapshot.Subscribers) > 0 {
+			t.Fatalf("%s still observed after Connect failed", snapshot.Name)
+		}
+	}
+	if bus.subscribers() != 0 {
+		t.Fatal("failed link still subscribed to the bus")
+	}
+}
@@ -0,0 +1,313 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+var ErrNoDiscovery = errors.New("Bridge link without discovery")
+
+// Discovery frames start with these bytes in place of a frame version. A
+// request carries the length prefixed origin of the link asking and a request
+// id, an answer the length prefixed origin of the link answering, then those
+// of the request, followed by its topics as JSON. Peers that predate discovery
+// report requests as ErrUnsupportedVersion
+const (
+	discoverFrame = 0x80
+	topicsFrame   = 0x81
+)
+
+// WithDiscovery makes the link answer the RemoteTopics requests of its peers
+// with the events of its notifier, and able to list and Watch the topics of
+// its peers in turn. Peers forward every exported notification to links with
+// discovery, as they may be watching
+func WithDiscovery() Option {
+	return func(link *Link) {
+		link.discovery = true
+	}
+}
+
+// RemoteTopic describes an event of a peer's notifier
+type RemoteTopic struct {
+	Origin      string    `json:"-"` // the peer's link
+	Name        string    `json:"name"`
+	Created     time.Time `json:"created,omitempty"`
+	Subscribers int       `json:"subscribers"` // including the peer's links
+	Pending     int       `json:"pending,omitempty"`
+	// Exported reports whether the peer forwards the event's notifications
+	// over the bus, so they can be watched
+	Exported bool `json:"exported,omitempty"`
+}
+
+// An outstanding RemoteTopics request
+type discovery struct {
+	expected map[string]bool // peers yet to answer
+	topics   []RemoteTopic
+	done     chan struct{}
+}
+
+// RemoteTopics asks the peers on the bus for the events of their notifiers,
+// sorted by origin and name. It returns once every peer known to support
+// discovery (see Peers) has answered, or with what has arrived so far and
+// ctx's error once it is done
+func (link *Link) RemoteTopics(ctx context.Context) ([]RemoteTopic, error) {
+	if !link.discovery {
+		return nil, ErrNoDiscovery
+	}
+	request := &discovery{expected: make(map[string]bool), done: make(chan struct{})}
+	for _, peer := range link.Peers() {
+		if peer.Capabilities.Has(CapabilityDiscovery) {
+			request.expected[peer.Origin] = true
+		}
+	}
+
+	waiting := len(request.expected) > 0
+
+	link.discoveryLock.Lock()
+	link.requestID++
+	id := link.requestID
+	if waiting {
+		link.requests[id] = request
+	}
+	link.discoveryLock.Unlock()
+
+	var err error
+	if waiting {
+		if err = link.publishControl(encodeDiscover(link.origin, id)); err == nil {
+			select {
+			case <-request.done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+	}
+
+	link.discoveryLock.Lock()
+	delete(link.requests, id)
+	topics := request.topics
+	link.discoveryLock.Unlock()
+
+	sort.Slice(topics, func(i, j int) bool {
+		if topics[i].Origin != topics[j].Origin {
+			return topics[i].Origin < topics[j].Origin
+		}
+		return topics[i].Name < topics[j].Name
+	})
+	return topics, err
+}
+
+// RemoteWatch delivers the notifications peers forward for events matching a
+// pattern, whether or not the local notifier has the events (see Link.Watch)
+type RemoteWatch struct {
+	C       <-chan notify.Envelope
+	c       chan notify.Envelope
+	pattern string
+	link    *Link
+	closed  bool
+}
+
+// Watch delivers the notifications exported by peers for events matching
+// pattern (see path.Match) as Envelopes with the peer's origin as their
+// Source, without posting them to the local notifier. Up to buffer
+// notifications are held until received; the rest are dropped rather than
+// holding up the link. The link must have been connected WithDiscovery
+func (link *Link) Watch(pattern string, buffer int) (*RemoteWatch, error) {
+	if !link.discovery {
+		return nil, ErrNoDiscovery
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	c := make(chan notify.Envelope, buffer)
+	watch := &RemoteWatch{C: c, c: c, pattern: pattern, link: link}
+
+	link.discoveryLock.Lock()
+	defer link.discoveryLock.Unlock()
+
+	if link.watchesClosed {
+		return nil, ErrBridgeClosed
+	}
+	link.watches = append(link.watches, watch)
+	return watch, nil
+}
+
+// Close stops the watch and closes its channel
+func (watch *RemoteWatch) Close() {
+	link := watch.link
+	link.discoveryLock.Lock()
+	defer link.discoveryLock.Unlock()
+
+	for i, w := range link.watches {
+		if w == watch {
+			link.watches = append(link.watches[:i:i], link.watches[i+1:]...)
+			break
+		}
+	}
+	watch.close()
+}
+
+// Must be called with the link's discovery lock held
+func (watch *RemoteWatch) close() {
+	if !watch.closed {
+		watch.closed = true
+		close(watch.c)
+	}
+}
+
+// Close every watch, once the link is closed
+func (link *Link) closeWatches() {
+	link.discoveryLock.Lock()
+	defer link.discoveryLock.Unlock()
+
+	link.watchesClosed = true
+	for _, watch := range link.watches {
+		watch.close()
+	}
+	link.watches = nil
+}
+
+func (link *Link) watched(event string) bool {
+	link.discoveryLock.Lock()
+	defer link.discoveryLock.Unlock()
+
+	for _, watch := range link.watches {
+		if matched, _ := path.Match(watch.pattern, event); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Hand a remote notification to the watches matching its event
+func (link *Link) deliverWatches(origin string, event string, data interface{}) {
+	link.discoveryLock.Lock()
+	defer link.discoveryLock.Unlock()
+
+	for _, watch := range link.watches {
+		if matched, _ := path.Match(watch.pattern, event); !matched {
+			continue
+		}
+		select {
+		case watch.c <- notify.Envelope{Event: event, Posted: link.clock.Now(), Source: origin, Data: data}:
+		default:
+		}
+	}
+}
+
+// The events of the local notifier, as listed to peers
+func (link *Link) localTopics() []RemoteTopic {
+	snapshots := link.notifier.Snapshot()
+	topics := make([]RemoteTopic, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		_, exported := link.outputChans[snapshot.Name]
+		topics = append(topics, RemoteTopic{
+			Name:        snapshot.Name,
+			Created:     snapshot.Created,
+			Subscribers: len(snapshot.Subscribers),
+			Pending:     snapshot.Pending,
+			Exported:    exported,
+		})
+	}
+	return topics
+}
+
+func encodeDiscover(origin string, id uint64) []byte {
+	frame := []byte{discoverFrame}
+	frame = binary.AppendUvarint(frame, uint64(len(origin)))
+	frame = append(frame, origin...)
+	return binary.AppendUvarint(frame, id)
+}
+
+func encodeTopics(origin string, requester string, id uint64, topics []byte) []byte {
+	frame := []byte{topicsFrame}
+	frame = binary.AppendUvarint(frame, uint64(len(origin)))
+	frame = append(frame, origin...)
+	frame = binary.AppendUvarint(frame, uint64(len(requester)))
+	frame = append(frame, requester...)
+	frame = binary.AppendUvarint(frame, id)
+	return append(frame, topics...)
+}
+
+// Read a length prefixed string off the front of frame
+func readString(frame []byte) (string, []byte, error) {
+	n, size := binary.Uvarint(frame)
+	if size <= 0 || uint64(len(frame)-size) < n {
+		return "", nil, ErrBadFrame
+	}
+	return string(frame[size : size+int(n)]), frame[size+int(n):], nil
+}
+
+// Answer a peer's request for the local topics
+func (link *Link) receiveDiscover(frame []byte) {
+	requester, rest, err := readString(frame[1:])
+	if err != nil {
+		link.reject("", "", frame, err)
+		return
+	}
+	id, size := binary.Uvarint(rest)
+	if size <= 0 {
+		link.reject("", "", frame, ErrBadFrame)
+		return
+	}
+	if requester == link.origin {
+		return
+	}
+
+	topics, err := json.Marshal(link.localTopics())
+	if err == nil {
+		err = link.publishControl(encodeTopics(link.origin, requester, id, topics))
+	}
+	if err != nil {
+		link.onError(err)
+	}
+}
+
+// Add a peer's answer to the request it was for, if it is still outstanding
+func (link *Link) receiveTopics(frame []byte) {
+	origin, rest, err := readString(frame[1:])
+	if err == nil {
+		var requester string
+		if requester, rest, err = readString(rest); err == nil && requester != link.origin {
+			return
+		}
+	}
+	if err != nil {
+		link.reject("", "", frame, err)
+		return
+	}
+	id, size := binary.Uvarint(rest)
+	if size <= 0 {
+		link.reject("", "", frame, ErrBadFrame)
+		return
+	}
+	var topics []RemoteTopic
+	if err := json.Unmarshal(rest[size:], &topics); err != nil {
+		link.reject(origin, "", frame, err)
+		return
+	}
+
+	link.discoveryLock.Lock()
+	defer link.discoveryLock.Unlock()
+
+	request, ok := link.requests[id]
+	if !ok || !request.expected[origin] {
+		return
+	}
+	for i := range topics {
+		topics[i].Origin = origin
+	}
+	request.topics = append(request.topics, topics...)
+	delete(request.expected, origin)
+	if len(request.expected) == 0 {
+		close(request.done)
+	}
+}
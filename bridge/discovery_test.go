@@ -0,0 +1,99 @@
+//go:build !notifyminimal
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jesus-ramos/go-notify/bridge"
+)
+
+func TestRemoteTopics(t *testing.T) {
+	bus := &memoryBus{}
+	remote := newNotifier(t)
+	remote.Start("orders", make(chan interface{}, 1))
+	remote.Start("payments", make(chan interface{}, 1))
+	connect(t, remote, bus, bridge.Export("orders"), bridge.WithDiscovery())
+	link := connect(t, newNotifier(t), bus, bridge.WithDiscovery())
+	// Peers without discovery aren't waited on
+	connect(t, newNotifier(t), bus)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	topics, err := link.RemoteTopics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var origin string
+	for _, peer := range link.Peers() {
+		if peer.Capabilities.Has(bridge.CapabilityDiscovery) {
+			origin = peer.Origin
+		}
+	}
+	if len(topics) != 2 {
+		t.Fatalf("listed %+v, want orders and payments", topics)
+	}
+	for i, want := range []bridge.RemoteTopic{
+		{Origin: origin, Name: "orders", Subscribers: 2, Exported: true},
+		{Origin: origin, Name: "payments", Subscribers: 1},
+	} {
+		got := topics[i]
+		if got.Origin != want.Origin || got.Name != want.Name || got.Subscribers != want.Subscribers || got.Exported != want.Exported {
+			t.Fatalf("listed %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestRemoteTopicsWithoutDiscovery(t *testing.T) {
+	link := connect(t, newNotifier(t), &memoryBus{})
+
+	if _, err := link.RemoteTopics(context.Background()); err != bridge.ErrNoDiscovery {
+		t.Fatalf("RemoteTopics returned %v, want ErrNoDiscovery", err)
+	}
+	if _, err := link.Watch("*", 1); err != bridge.ErrNoDiscovery {
+		t.Fatalf("Watch returned %v, want ErrNoDiscovery", err)
+	}
+}
+
+func TestRemoteWatch(t *testing.T) {
+	bus := &memoryBus{}
+	remote := newNotifier(t)
+	connect(t, remote, bus, bridge.Export("orders", "payments"))
+	local := newNotifier(t)
+	link := connect(t, local, bus, bridge.WithDiscovery())
+
+	watch, err := link.Watch("ord*", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputChan := make(chan interface{}, 1)
+	local.Start("orders", outputChan)
+
+	remote.Post("payments", "payment 1")
+	remote.Post("orders", "order 1")
+	select {
+	case envelope := <-watch.C:
+		if envelope.Event != "orders" || envelope.Data != "order 1" || envelope.Source != link.Peers()[0].Origin {
+			t.Fatalf("watched %+v, want order 1 from the remote link", envelope)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing watched")
+	}
+	select {
+	case data := <-outputChan:
+		t.Fatalf("watched notification %v posted locally", data)
+	case envelope := <-watch.C:
+		t.Fatalf("watched %+v, which doesn't match", envelope)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	link.Close()
+	if _, ok := <-watch.C; ok {
+		t.Fatal("watch left open once the link closed")
+	}
+	if _, err := link.Watch("*", 1); err != bridge.ErrBridgeClosed {
+		t.Fatalf("Watch on a closed link returned %v, want ErrBridgeClosed", err)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"path"
+)
+
+var ErrDecrypt = errors.New("Unable to decrypt bridged notification")
+
+// Key used for the events matching any of its patterns
+type topicKey struct {
+	patterns []string
+	aead     cipher.AEAD
+}
+
+// Encrypt seals notifications of events matching any of the patterns (see
+// path.Match) with AES-GCM under key, which must be 16, 24 or 32 bytes long,
+// so only peers holding the key can read them. Event names are still sent in
+// the clear for routing. Notifications for these events that fail to decrypt,
+// including ones sent unencrypted, are reported as ErrDecrypt and dropped.
+// When several keys match an event the first one given is used
+func Encrypt(key []byte, patterns ...string) Option {
+	return func(link *Link) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			link.err = err
+			return
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			link.err = err
+			return
+		}
+		link.keys = append(link.keys, topicKey{patterns: patterns, aead: aead})
+	}
+}
+
+// The AEAD for event, nil if its notifications aren't encrypted
+func (link *Link) key(event string) cipher.AEAD {
+	for _, key := range link.keys {
+		for _, pattern := range key.patterns {
+			if matched, _ := path.Match(pattern, event); matched {
+				return key.aead
+			}
+		}
+	}
+	return nil
+}
+
+// Encrypt an encoded notification for event, binding it to the event name.
+// The result is the nonce followed by the sealed payload
+func (link *Link) seal(event string, payload []byte) ([]byte, error) {
+	aead := link.key(event)
+	if aead == nil {
+		return payload, nil
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, payload, []byte(event)), nil
+}
+
+func (link *Link) open(event string, payload []byte) ([]byte, error) {
+	aead := link.key(event)
+	if aead == nil {
+		return payload, nil
+	}
+	if len(payload) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+	opened, err := aead.Open(nil, nonce, sealed, []byte(event))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return opened, nil
+}
@@ -0,0 +1,96 @@
+//go:build !notifyminimal
+
+package bridge_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jesus-ramos/go-notify/bridge"
+)
+
+var (
+	orderKey = bytes.Repeat([]byte{1}, 32)
+	otherKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestLinkEncrypts(t *testing.T) {
+	bus := &memoryBus{}
+	exporter, received := pair(t, bus, "orders",
+		[]bridge.Option{bridge.Encrypt(orderKey, "orders")},
+		[]bridge.Option{bridge.Encrypt(orderKey, "ord*")})
+
+	var sent [][]byte
+	bus.tamperNotifications(func(frame []byte) []byte {
+		sent = append(sent, frame)
+		return frame
+	})
+	for i := 0; i < 2; i++ {
+		exporter.Post("orders", "order 1")
+		if got := receive(t, received); got != "order 1" {
+			t.Fatalf("imported %v, want order 1", got)
+		}
+	}
+	if bytes.Contains(sent[0], []byte("order 1")) {
+		t.Fatal("notification forwarded in the clear")
+	}
+	if bytes.Equal(sent[0], sent[1]) {
+		t.Fatal("same nonce used twice")
+	}
+}
+
+func TestLinkRejectsUndecryptable(t *testing.T) {
+	// Sealed "order 1" as JSON: a 12 byte nonce then 9 bytes and a 16 byte tag
+	const sealed = 12 + 9 + 16
+	for _, test := range []struct {
+		name               string
+		exporter, importer []bridge.Option
+		tamper             func(frame []byte) []byte
+	}{
+		{name: "wrong key", exporter: []bridge.Option{bridge.Encrypt(otherKey, "orders")}, importer: []bridge.Option{bridge.Encrypt(orderKey, "orders")}},
+		{name: "sent in the clear", importer: []bridge.Option{bridge.Encrypt(orderKey, "orders")}},
+		{name: "no key", exporter: []bridge.Option{bridge.Encrypt(orderKey, "orders")}},
+		{name: "tampered ciphertext", exporter: []bridge.Option{bridge.Encrypt(orderKey, "orders")}, importer: []bridge.Option{bridge.Encrypt(orderKey, "orders")},
+			tamper: func(frame []byte) []byte {
+				frame[len(frame)-1] ^= 1
+				return frame
+			}},
+		{name: "tampered nonce", exporter: []bridge.Option{bridge.Encrypt(orderKey, "orders")}, importer: []bridge.Option{bridge.Encrypt(orderKey, "orders")},
+			tamper: func(frame []byte) []byte {
+				frame[len(frame)-sealed] ^= 1
+				return frame
+			}},
+		{name: "truncated", exporter: []bridge.Option{bridge.Encrypt(orderKey, "orders")}, importer: []bridge.Option{bridge.Encrypt(orderKey, "orders")},
+			tamper: func(frame []byte) []byte {
+				return frame[:len(frame)-sealed+4]
+			}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bus := &memoryBus{}
+			errs := make(errorLog, 16)
+			exporter, received := pair(t, bus, "orders", test.exporter, append(test.importer, errs.handler()))
+
+			if test.tamper != nil {
+				bus.tamperNotifications(test.tamper)
+			}
+			exporter.Post("orders", "order 1")
+			errs.expect(t, bridge.ErrDecrypt)
+			nothing(t, received)
+		})
+	}
+}
+
+func TestLinkEncryptionBindsEvent(t *testing.T) {
+	bus := &memoryBus{}
+	errs := make(errorLog, 16)
+	key := bridge.Encrypt(orderKey, "orders", "refunds")
+	exporter, received := pair(t, bus, "refunds", []bridge.Option{key, bridge.Export("orders")}, []bridge.Option{key, bridge.Import("orders"), errs.handler()})
+
+	// Forward an orders notification as a refund
+	bus.tamperNotifications(func(frame []byte) []byte {
+		return bytes.Replace(frame, []byte("\x06orders"), []byte("\x07refunds"), 1)
+	})
+	exporter.Post("orders", "order 1")
+	errs.expect(t, bridge.ErrDecrypt)
+	nothing(t, received)
+}
@@ -0,0 +1,222 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Frames are a version byte followed by the length prefixed origin and event
+// name, and finally the encoded notification. Signed frames also carry a
+// length prefixed signature of the unsigned frame after the event name.
+// Flagged frames always carry the signature, empty if unsigned, followed by a
+// flags byte and, with flagAck, the sequence number to acknowledge the
+// notification with. Links only send the versions every known peer reads
+// (see Peer)
+const (
+	frameVersion        = 1
+	signedFrameVersion  = 2
+	flaggedFrameVersion = 3
+)
+
+// Flags of flagged frames
+const (
+	flagCompressed = 1 << iota // deflated before being sealed
+	flagEncrypted              // sealed with the event's key (see Encrypt)
+	flagAck                    // to be acknowledged (see WithAcks)
+)
+
+// Inflated notifications larger than this are rejected as ErrBadFrame
+const maxInflated = 64 << 20
+
+// WithCompression deflates the notifications the link forwards, as long as
+// every known peer reads compressed notifications. They are sent as they are
+// otherwise
+func WithCompression() Option {
+	return func(link *Link) {
+		link.compress = true
+	}
+}
+
+// A notification frame
+type frame struct {
+	version   byte
+	origin    string
+	event     string
+	signature []byte // nil if unsigned
+	flags     byte
+	seq       uint64
+	payload   []byte
+}
+
+func (f *frame) encode() []byte {
+	return f.appendTo(make([]byte, 0, 1+4*binary.MaxVarintLen64+1+len(f.origin)+len(f.event)+len(f.signature)+len(f.payload)), f.signature)
+}
+
+// The bytes the frame's signature covers: the frame as unsigned version 1 or,
+// for flagged frames, with an empty signature
+func (f *frame) signed() []byte {
+	unsigned := *f
+	if f.version == signedFrameVersion {
+		unsigned.version = frameVersion
+	}
+	return unsigned.appendTo(nil, nil)
+}
+
+func (f *frame) appendTo(out []byte, signature []byte) []byte {
+	out = append(out, f.version)
+	out = binary.AppendUvarint(out, uint64(len(f.origin)))
+	out = append(out, f.origin...)
+	out = binary.AppendUvarint(out, uint64(len(f.event)))
+	out = append(out, f.event...)
+	if f.version >= signedFrameVersion {
+		out = binary.AppendUvarint(out, uint64(len(signature)))
+		out = append(out, signature...)
+	}
+	if f.version == flaggedFrameVersion {
+		out = append(out, f.flags)
+		if f.flags&flagAck != 0 {
+			out = binary.AppendUvarint(out, f.seq)
+		}
+	}
+	return append(out, f.payload...)
+}
+
+// Decode a frame of any version
+func decodeFrame(encoded []byte) (*frame, error) {
+	if len(encoded) == 0 {
+		return nil, ErrBadFrame
+	}
+	if encoded[0] > ProtocolVersion {
+		return nil, fmt.Errorf("%w: frame version %d, newest supported is %d", ErrUnsupportedVersion, encoded[0], ProtocolVersion)
+	}
+	if encoded[0] < frameVersion {
+		return nil, ErrBadFrame
+	}
+	f := &frame{version: encoded[0]}
+	rest := encoded[1:]
+	count := 2
+	if f.version >= signedFrameVersion {
+		count = 3
+	}
+	fields := make([][]byte, count)
+	for i := range fields {
+		n, size := binary.Uvarint(rest)
+		if size <= 0 || uint64(len(rest)-size) < n {
+			return nil, ErrBadFrame
+		}
+		fields[i] = rest[size : size+int(n)]
+		rest = rest[size+int(n):]
+	}
+	f.origin, f.event = string(fields[0]), string(fields[1])
+	if count == 3 && len(fields[2]) > 0 {
+		f.signature = fields[2]
+	}
+
+	if f.version == flaggedFrameVersion {
+		if len(rest) == 0 {
+			return nil, ErrBadFrame
+		}
+		f.flags, rest = rest[0], rest[1:]
+		if f.flags&flagAck != 0 {
+			seq, size := binary.Uvarint(rest)
+			if size <= 0 {
+				return nil, ErrBadFrame
+			}
+			f.seq, rest = seq, rest[size:]
+		}
+	}
+	f.payload = rest
+	return f, nil
+}
+
+// Build the frame forwarding data to event in the newest form of version
+// that the capabilities shared by the peers allow, asking for it to be
+// acknowledged with seq unless it is zero
+func (link *Link) newFrame(event string, data interface{}, version int, shared Capability, seq uint64) (*frame, error) {
+	payload, err := link.codec.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	f := &frame{version: frameVersion, origin: link.origin, event: event}
+	if version >= flaggedFrameVersion {
+		f.version = flaggedFrameVersion
+		if link.compress && shared.Has(CapabilityCompression) {
+			if payload, err = deflate(payload); err != nil {
+				return nil, err
+			}
+			f.flags |= flagCompressed
+		}
+		if link.key(event) != nil {
+			f.flags |= flagEncrypted
+		}
+		if seq != 0 {
+			f.flags |= flagAck
+			f.seq = seq
+		}
+	}
+	if f.payload, err = link.seal(event, payload); err != nil {
+		return nil, err
+	}
+	if link.signer != nil {
+		if f.version == frameVersion {
+			f.version = signedFrameVersion
+		}
+		if f.signature, err = link.signer.Sign(f.signed()); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Check, open and decode the notification a frame carries
+func (link *Link) readFrame(f *frame) (interface{}, error) {
+	if link.signer != nil && (f.signature == nil || !link.signer.Verify(f.signed(), f.signature)) {
+		return nil, ErrBadSignature
+	}
+	// Flagged frames tell whether they are encrypted, so a missing or extra
+	// key is reported as such rather than as a codec error
+	if f.version == flaggedFrameVersion && (f.flags&flagEncrypted != 0) != (link.key(f.event) != nil) {
+		return nil, ErrDecrypt
+	}
+	payload, err := link.open(f.event, f.payload)
+	if err != nil {
+		return nil, err
+	}
+	if f.flags&flagCompressed != 0 {
+		if payload, err = inflate(payload); err != nil {
+			return nil, err
+		}
+	}
+	return link.codec.Unmarshal(payload)
+}
+
+func deflate(payload []byte) ([]byte, error) {
+	var out bytes.Buffer
+	writer, err := flate.NewWriter(&out, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func inflate(payload []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(payload))
+	defer reader.Close()
+
+	out, err := io.ReadAll(io.LimitReader(reader, maxInflated+1))
+	if err != nil || len(out) > maxInflated {
+		return nil, ErrBadFrame
+	}
+	return out, nil
+}
@@ -0,0 +1,73 @@
+//go:build !notifyminimal
+
+package bridge_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jesus-ramos/go-notify/bridge"
+)
+
+func TestLinkCompresses(t *testing.T) {
+	bus := &memoryBus{}
+	exporter, received := pair(t, bus, "orders", []bridge.Option{bridge.WithCompression()}, nil)
+
+	order := strings.Repeat("order ", 1000)
+	var sent []byte
+	bus.tamperNotifications(func(frame []byte) []byte {
+		sent = frame
+		return frame
+	})
+	exporter.Post("orders", order)
+	if got := receive(t, received); got != order {
+		t.Fatalf("imported %d bytes, want the %d posted", len(got.(string)), len(order))
+	}
+	if len(sent) >= len(order) {
+		t.Fatalf("forwarded %d bytes for %d bytes of JSON", len(sent), len(order))
+	}
+}
+
+func TestLinkRejectsMalformedFrames(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		tamper func(frame []byte) []byte
+		err    error
+	}{
+		{name: "truncated header", tamper: func(frame []byte) []byte { return frame[:3] }, err: bridge.ErrBadFrame},
+		{name: "length past the end", tamper: func(frame []byte) []byte { return append(frame[:1], 0x7f) }, err: bridge.ErrBadFrame},
+		{name: "version 0xff", tamper: func(frame []byte) []byte {
+			frame[0] = bridge.ProtocolVersion + 1
+			return frame
+		}, err: bridge.ErrUnsupportedVersion},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bus := &memoryBus{}
+			errs := make(errorLog, 16)
+			exporter, received := pair(t, bus, "orders", nil, []bridge.Option{errs.handler()})
+
+			bus.tamperNotifications(test.tamper)
+			exporter.Post("orders", "order 1")
+			errs.expect(t, test.err)
+			nothing(t, received)
+		})
+	}
+}
+
+func TestLinkReadsOlderFrames(t *testing.T) {
+	bus := &memoryBus{}
+	exporter, received := pair(t, bus, "orders", nil, nil)
+
+	// What a peer writing version 1 frames would send
+	bus.tamperNotifications(func(frame []byte) []byte {
+		if !bytes.HasSuffix(frame, []byte(`"order 1"`)) {
+			t.Errorf("unexpected frame %q", frame)
+		}
+		return append([]byte{1, 1, 'x', 6}, append([]byte("orders"), `"order 2"`...)...)
+	})
+	exporter.Post("orders", "order 1")
+	if got := receive(t, received); got != "order 2" {
+		t.Fatalf("imported %v, want the version 1 frame's order 2", got)
+	}
+}
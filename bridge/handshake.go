@@ -0,0 +1,313 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("Unsupported bridge protocol version")
+	ErrPeerIncompatible   = errors.New("Incompatible bridge peer")
+	ErrPeerLimit          = errors.New("Bridge peer limit reached")
+)
+
+// ProtocolVersion is the newest frame version links understand. Links
+// announce it, along with their capabilities, in a hello frame when they
+// connect, in reply to the hello of every peer they haven't met and every
+// half peer TTL (see WithPeerTTL)
+const ProtocolVersion = flaggedFrameVersion
+
+const (
+	// DefaultPeerLimit is the number of peers a link keeps track of unless
+	// WithPeerLimit says otherwise
+	DefaultPeerLimit = 1024
+	// DefaultPeerTTL is how long a peer is remembered after its last hello
+	// unless WithPeerTTL says otherwise
+	DefaultPeerTTL = time.Minute
+)
+
+// Capability flags advertised in the hello frame
+type Capability uint64
+
+const (
+	// The link signs its frames (see WithSigner) and rejects unsigned ones
+	CapabilitySigning Capability = 1 << iota
+	// Some of the link's topics are encrypted (see Encrypt)
+	CapabilityEncryption
+	// Rejected frames are quarantined rather than dropped (see Quarantine)
+	CapabilityQuarantine
+	// The link answers RemoteTopics requests (see WithDiscovery)
+	CapabilityDiscovery
+	// The link reads compressed notifications (see WithCompression)
+	CapabilityCompression
+	// The link acknowledges the notifications it imports when asked to (see
+	// WithAcks)
+	CapabilityAck
+	// The link lists the patterns it imports in its hello, so peers only
+	// forward notifications someone imports
+	CapabilityFilters
+)
+
+// Has reports whether every capability in flags is set
+func (capabilities Capability) Has(flags Capability) bool {
+	return capabilities&flags == flags
+}
+
+// Peer is a remote link met through the hello handshake
+type Peer struct {
+	Origin       string
+	Version      int
+	Capabilities Capability
+	Imports      []string  // the patterns it imports, with CapabilityFilters
+	Seen         time.Time // when its last hello arrived
+}
+
+// WithPeerLimit caps the number of peers the link keeps track of. Hellos from
+// further peers are reported as ErrPeerLimit and ignored until others expire
+func WithPeerLimit(limit int) Option {
+	return func(link *Link) {
+		link.peerLimit = limit
+	}
+}
+
+// WithPeerTTL forgets peers that haven't sent a hello for ttl. Links announce
+// themselves every half ttl, so peers that are still there are kept. Zero
+// keeps peers, and stops announcing, for as long as the link lives
+func WithPeerTTL(ttl time.Duration) Option {
+	return func(link *Link) {
+		link.peerTTL = ttl
+	}
+}
+
+// Hello frames start with this byte in place of a frame version, followed by
+// the length prefixed origin, the protocol version and the capability flags.
+// With CapabilityFilters they go on with the number of import patterns and
+// each length prefixed pattern
+const helloFrame = 0
+
+// Peers returns the remote links that have announced themselves and haven't
+// expired, sorted by origin
+func (link *Link) Peers() []Peer {
+	link.peerLock.Lock()
+	defer link.peerLock.Unlock()
+
+	peers := make([]Peer, 0, len(link.peers))
+	for _, peer := range link.peers {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Origin < peers[j].Origin })
+	return peers
+}
+
+// The capabilities the link announces
+func (link *Link) capabilities() Capability {
+	capabilities := CapabilityCompression | CapabilityAck | CapabilityFilters
+	if link.signer != nil {
+		capabilities |= CapabilitySigning
+	}
+	if len(link.keys) > 0 {
+		capabilities |= CapabilityEncryption
+	}
+	if link.quarantine {
+		capabilities |= CapabilityQuarantine
+	}
+	if link.discovery {
+		capabilities |= CapabilityDiscovery
+	}
+	return capabilities
+}
+
+// Announce the link to its peers. Links whose signer can't sign, like an
+// Ed25519Signer without a private key, stay unannounced
+func (link *Link) hello() error {
+	frame := []byte{helloFrame}
+	frame = binary.AppendUvarint(frame, uint64(len(link.origin)))
+	frame = append(frame, link.origin...)
+	frame = binary.AppendUvarint(frame, ProtocolVersion)
+	frame = binary.AppendUvarint(frame, uint64(link.capabilities()))
+	frame = binary.AppendUvarint(frame, uint64(len(link.imports)))
+	for _, pattern := range link.imports {
+		frame = binary.AppendUvarint(frame, uint64(len(pattern)))
+		frame = append(frame, pattern...)
+	}
+	if err := link.publishControl(frame); err != ErrBadSignature {
+		return err
+	}
+	return nil
+}
+
+func decodeHello(frame []byte) (Peer, error) {
+	origin, rest, err := readString(frame[1:])
+	if err != nil {
+		return Peer{}, err
+	}
+	peer := Peer{Origin: origin}
+
+	version, size := binary.Uvarint(rest)
+	if size <= 0 {
+		return Peer{}, ErrBadFrame
+	}
+	rest = rest[size:]
+	capabilities, size := binary.Uvarint(rest)
+	if size <= 0 {
+		return Peer{}, ErrBadFrame
+	}
+	rest = rest[size:]
+	peer.Version = int(version)
+	peer.Capabilities = Capability(capabilities)
+	if !peer.Capabilities.Has(CapabilityFilters) {
+		return peer, nil
+	}
+
+	count, size := binary.Uvarint(rest)
+	if size <= 0 || count > uint64(len(rest)) {
+		return Peer{}, ErrBadFrame
+	}
+	rest = rest[size:]
+	peer.Imports = make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var pattern string
+		if pattern, rest, err = readString(rest); err != nil {
+			return Peer{}, err
+		}
+		peer.Imports = append(peer.Imports, pattern)
+	}
+	return peer, nil
+}
+
+// Record a peer's hello, answering it if the peer is new and reporting
+// mismatches that will keep the two links from exchanging notifications
+func (link *Link) receiveHello(frame []byte) {
+	peer, err := decodeHello(frame)
+	if err != nil {
+		link.reject("", "", frame, err)
+		return
+	}
+	if peer.Origin == link.origin {
+		return
+	}
+	peer.Seen = link.clock.Now()
+
+	link.peerLock.Lock()
+	_, known := link.peers[peer.Origin]
+	if !known && len(link.peers) >= link.peerLimit {
+		link.expirePeers(peer.Seen)
+	}
+	full := !known && len(link.peers) >= link.peerLimit
+	if !full {
+		link.peers[peer.Origin] = peer
+	}
+	link.peerLock.Unlock()
+	if full {
+		link.onError(fmt.Errorf("%w: ignoring peer %s", ErrPeerLimit, peer.Origin))
+		return
+	}
+	if known {
+		return
+	}
+
+	if link.signer != nil && peer.Version < signedFrameVersion {
+		link.onError(fmt.Errorf("%w: peer %s can't read signed frames", ErrPeerIncompatible, peer.Origin))
+	}
+	if err := link.hello(); err != nil {
+		link.onError(err)
+	}
+}
+
+// Forget the peers that haven't sent a hello for the peer TTL. Must be called
+// with the peer lock held
+func (link *Link) expirePeers(now time.Time) {
+	if link.peerTTL <= 0 {
+		return
+	}
+	for origin, peer := range link.peers {
+		if now.Sub(peer.Seen) >= link.peerTTL {
+			delete(link.peers, origin)
+		}
+	}
+}
+
+// Announce the link every half peer TTL and forget the peers that went
+// quiet, until the link is closed
+func (link *Link) heartbeat() {
+	ticker := link.clock.NewTicker(link.peerTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-link.stop:
+			return
+		case <-ticker.C():
+		}
+		if err := link.hello(); err != nil {
+			link.onError(err)
+		}
+		link.peerLock.Lock()
+		link.expirePeers(link.clock.Now())
+		link.peerLock.Unlock()
+	}
+}
+
+// The newest frame version and the capabilities every known peer shares, so
+// frames are only sent in a form all of them can read. With no peers known
+// frames are sent in the oldest form
+func (link *Link) shared() (int, Capability) {
+	link.peerLock.Lock()
+	defer link.peerLock.Unlock()
+
+	if len(link.peers) == 0 {
+		return frameVersion, 0
+	}
+	version, capabilities := ProtocolVersion, ^Capability(0)
+	for _, peer := range link.peers {
+		version = min(version, peer.Version)
+		capabilities &= peer.Capabilities
+	}
+	return version, capabilities
+}
+
+// Whether any peer may want the notifications of event: one imports it, can
+// watch it (see WithDiscovery) or doesn't list what it imports
+func (link *Link) wanted(event string) bool {
+	link.peerLock.Lock()
+	defer link.peerLock.Unlock()
+
+	if len(link.peers) == 0 {
+		return true
+	}
+	for _, peer := range link.peers {
+		if !peer.Capabilities.Has(CapabilityFilters) || peer.Capabilities.Has(CapabilityDiscovery) || peer.imports(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// The known peers that import event and acknowledge what they import
+func (link *Link) ackers(event string) map[string]bool {
+	link.peerLock.Lock()
+	defer link.peerLock.Unlock()
+
+	ackers := make(map[string]bool)
+	for origin, peer := range link.peers {
+		if peer.Capabilities.Has(CapabilityAck|CapabilityFilters) && peer.imports(event) {
+			ackers[origin] = true
+		}
+	}
+	return ackers
+}
+
+func (peer Peer) imports(event string) bool {
+	for _, pattern := range peer.Imports {
+		if matched, _ := path.Match(pattern, event); matched {
+			return true
+		}
+	}
+	return false
+}
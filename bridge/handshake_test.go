@@ -0,0 +1,200 @@
+//go:build !notifyminimal
+
+package bridge_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/jesus-ramos/go-notify/bridge"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+// A hello as sent by a peer speaking version with capabilities
+func hello(origin string, version int, capabilities bridge.Capability) []byte {
+	frame := []byte{0}
+	frame = binary.AppendUvarint(frame, uint64(len(origin)))
+	frame = append(frame, origin...)
+	frame = binary.AppendUvarint(frame, uint64(version))
+	return binary.AppendUvarint(frame, uint64(capabilities))
+}
+
+// The notification frames published on bus from now on
+func (bus *memoryBus) notifications() <-chan []byte {
+	frames := make(chan []byte, 16)
+	bus.tamperNotifications(func(frame []byte) []byte {
+		select {
+		case frames <- frame:
+		default:
+		}
+		return frame
+	})
+	return frames
+}
+
+func TestLinkHandshake(t *testing.T) {
+	bus := &memoryBus{}
+	importer := connect(t, newNotifier(t), bus, bridge.Import("orders", "payments.*"))
+	exporter := connect(t, newNotifier(t), bus, bridge.Export("orders"),
+		bridge.Encrypt(orderKey, "orders"),
+		bridge.Quarantine(""),
+		bridge.WithDiscovery())
+
+	peers := exporter.Peers()
+	if len(peers) != 1 {
+		t.Fatalf("exporter knows %v, want the importer", peers)
+	}
+	if peer := peers[0]; peer.Version != bridge.ProtocolVersion ||
+		peer.Capabilities != bridge.CapabilityCompression|bridge.CapabilityAck|bridge.CapabilityFilters ||
+		len(peer.Imports) != 2 || peer.Imports[0] != "orders" || peer.Imports[1] != "payments.*" {
+		t.Fatalf("importer announced %+v", peer)
+	}
+
+	peers = importer.Peers()
+	if len(peers) != 1 {
+		t.Fatalf("importer knows %v, want the exporter", peers)
+	}
+	want := bridge.CapabilityEncryption | bridge.CapabilityQuarantine | bridge.CapabilityDiscovery
+	if peer := peers[0]; !peer.Capabilities.Has(want) || peer.Capabilities.Has(bridge.CapabilitySigning) || len(peer.Imports) != 0 {
+		t.Fatalf("exporter announced %+v", peer)
+	}
+}
+
+func TestLinkSignedHandshake(t *testing.T) {
+	bus := &memoryBus{}
+	signer := bridge.WithSigner(bridge.HMACSigner([]byte("key")))
+	first := connect(t, newNotifier(t), bus, signer)
+	stranger := connect(t, newNotifier(t), bus)
+	connect(t, newNotifier(t), bus, signer)
+
+	peers := first.Peers()
+	if len(peers) != 1 || !peers[0].Capabilities.Has(bridge.CapabilitySigning) {
+		t.Fatalf("signing link knows %+v, want only the other signing link", peers)
+	}
+	// Links without a signer still read signed hellos
+	if peers := stranger.Peers(); len(peers) != 2 {
+		t.Fatalf("link without a signer knows %+v, want both signing links", peers)
+	}
+}
+
+func TestLinkDegradesForOlderPeers(t *testing.T) {
+	bus := &memoryBus{}
+	errs := make(errorLog, 16)
+	notifier := newNotifier(t)
+	connect(t, notifier, bus, bridge.Export("orders"), bridge.WithCompression(),
+		bridge.WithSigner(bridge.HMACSigner([]byte("key"))), errs.handler())
+	frames := bus.notifications()
+
+	// A version 1 peer, which neither reads signed frames nor signs its hello
+	bus.client().Publish(hello("old", 1, 0))
+	errs.expect(t, bridge.ErrBadSignature)
+
+	// The same peer announcing itself signed, as an HMAC peer would
+	signed := bridge.HMACSigner([]byte("key"))
+	frame := hello("old", 1, 0)
+	signature, _ := signed.Sign(frame)
+	bus.client().Publish(append(append([]byte{0x82, byte(len(signature))}, signature...), frame...))
+	errs.expect(t, bridge.ErrPeerIncompatible)
+
+	notifier.Post("orders", "order 1")
+	select {
+	case frame := <-frames:
+		if frame[0] != 2 {
+			t.Fatalf("forwarded a version %d frame to a version 1 peer, want signed version 2", frame[0])
+		}
+		if !bytes.HasSuffix(frame, []byte(`"order 1"`)) {
+			t.Fatal("compressed for a peer that can't inflate")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing forwarded")
+	}
+}
+
+func TestLinkForwardsOnlyWhatPeersImport(t *testing.T) {
+	bus := &memoryBus{}
+	notifier := newNotifier(t)
+	connect(t, newNotifier(t), bus, bridge.Import("orders"))
+	connect(t, notifier, bus, bridge.Export("orders", "payments"))
+	frames := bus.notifications()
+
+	notifier.Post("payments", 1)
+	notifier.Post("orders", 1)
+	select {
+	case frame := <-frames:
+		if !bytes.Contains(frame, []byte("\x06orders")) {
+			t.Fatalf("forwarded %q, which no peer imports", frame)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing forwarded")
+	}
+	select {
+	case frame := <-frames:
+		t.Fatalf("forwarded %q, which no peer imports", frame)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestLinkRejectsMalformedHello(t *testing.T) {
+	bus := &memoryBus{}
+	errs := make(errorLog, 16)
+	link := connect(t, newNotifier(t), bus, errs.handler())
+
+	bus.client().Publish(hello("peer", 3, 0)[:4])
+	errs.expect(t, bridge.ErrBadFrame)
+	if peers := link.Peers(); len(peers) != 0 {
+		t.Fatalf("recorded %+v from a malformed hello", peers)
+	}
+}
+
+func TestLinkPeerLimit(t *testing.T) {
+	bus := &memoryBus{}
+	errs := make(errorLog, 16)
+	link := connect(t, newNotifier(t), bus, bridge.WithPeerLimit(1), errs.handler())
+
+	bus.client().Publish(hello("first", 3, 0))
+	bus.client().Publish(hello("second", 3, 0))
+	errs.expect(t, bridge.ErrPeerLimit)
+	if peers := link.Peers(); len(peers) != 1 || peers[0].Origin != "first" {
+		t.Fatalf("kept %+v, want the first peer only", peers)
+	}
+}
+
+func TestLinkExpiresPeers(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	bus := &memoryBus{}
+	link := connect(t, newNotifier(t), bus, bridge.WithClock(clock), bridge.WithPeerTTL(time.Minute))
+
+	announced := make(chan struct{}, 16)
+	bus.client().Subscribe(func(frame []byte) {
+		if frame[0] == 0 {
+			announced <- struct{}{}
+		}
+	})
+	bus.client().Publish(hello("peer", 3, 0))
+	if peers := link.Peers(); len(peers) != 1 {
+		t.Fatalf("link knows %+v, want the peer", peers)
+	}
+	<-announced
+
+	// Announced every half TTL, so the peer, gone quiet, expires on the second
+	for i := 0; i < 2; i++ {
+		for clock.Timers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(30 * time.Second)
+		select {
+		case <-announced:
+		case <-time.After(time.Second):
+			t.Fatal("link didn't announce itself")
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(link.Peers()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("quiet peer not forgotten after its TTL")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,250 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DialTimeout bounds how long connecting to a message bus may take
+var DialTimeout = 5 * time.Second
+
+// NATSOption configures a NATS bridge
+type NATSOption func(*natsConnect)
+
+// NATSCredentials authenticates with a user name and password
+func NATSCredentials(user string, password string) NATSOption {
+	return func(connect *natsConnect) {
+		connect.User = user
+		connect.Pass = password
+	}
+}
+
+// NATSToken authenticates with a token
+func NATSToken(token string) NATSOption {
+	return func(connect *natsConnect) {
+		connect.AuthToken = token
+	}
+}
+
+// Options sent to the server in the CONNECT handshake
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Echo      bool   `json:"echo"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	Protocol  int    `json:"protocol"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// NATS is a Bridge publishing frames to a single NATS subject. It speaks the
+// plain text client protocol directly and does not reconnect; a dropped
+// connection is reported by Err and the bridge must be replaced
+type NATS struct {
+	subject string
+	conn    net.Conn
+	reader  *bufio.Reader
+
+	writeLock sync.Mutex
+	writer    *bufio.Writer
+
+	handlerLock sync.Mutex
+	handler     func(frame []byte)
+
+	done    chan struct{}
+	errLock sync.Mutex
+	err     error
+}
+
+// DialNATS connects to the NATS server at addr, exchanging frames on subject
+func DialNATS(addr string, subject string, options ...NATSOption) (*NATS, error) {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("bridge: invalid NATS subject %q", subject)
+	}
+	connect := natsConnect{Name: "go-notify", Lang: "go", Protocol: 1}
+	for _, option := range options {
+		option(&connect)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	nats := &NATS{
+		subject: subject,
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		writer:  bufio.NewWriter(conn),
+		done:    make(chan struct{}),
+	}
+	if err := nats.handshake(connect); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go nats.readLoop()
+
+	return nats, nil
+}
+
+func (nats *NATS) handshake(connect natsConnect) error {
+	nats.conn.SetDeadline(time.Now().Add(DialTimeout))
+	defer nats.conn.SetDeadline(time.Time{})
+
+	line, err := nats.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("bridge: unexpected NATS greeting %q", line)
+	}
+
+	options, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(nats.writer, "CONNECT %s\r\nPING\r\n", options)
+	if err := nats.writer.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		line, err := nats.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("bridge: NATS %s", line)
+		}
+	}
+}
+
+func (nats *NATS) Publish(frame []byte) error {
+	nats.writeLock.Lock()
+	defer nats.writeLock.Unlock()
+
+	if err := nats.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintf(nats.writer, "PUB %s %d\r\n", nats.subject, len(frame))
+	nats.writer.Write(frame)
+	nats.writer.WriteString("\r\n")
+	return nats.writer.Flush()
+}
+
+func (nats *NATS) Subscribe(handler func(frame []byte)) error {
+	nats.handlerLock.Lock()
+	if nats.handler != nil {
+		nats.handlerLock.Unlock()
+		return ErrAlreadySubscribed
+	}
+	nats.handler = handler
+	nats.handlerLock.Unlock()
+
+	nats.writeLock.Lock()
+	defer nats.writeLock.Unlock()
+
+	fmt.Fprintf(nats.writer, "SUB %s 1\r\n", nats.subject)
+	return nats.writer.Flush()
+}
+
+func (nats *NATS) Close() error {
+	nats.setErr(ErrBridgeClosed)
+	err := nats.conn.Close()
+	<-nats.done
+	return err
+}
+
+// Err returns the error that terminated the connection, if any
+func (nats *NATS) Err() error {
+	nats.errLock.Lock()
+	defer nats.errLock.Unlock()
+
+	return nats.err
+}
+
+func (nats *NATS) setErr(err error) {
+	nats.errLock.Lock()
+	defer nats.errLock.Unlock()
+
+	if nats.err == nil {
+		nats.err = err
+	}
+}
+
+func (nats *NATS) readLoop() {
+	defer close(nats.done)
+
+	for {
+		line, err := nats.readLine()
+		if err != nil {
+			nats.setErr(err)
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			frame, err := nats.readMsg(line)
+			if err != nil {
+				nats.setErr(err)
+				return
+			}
+			if handler := nats.currentHandler(); handler != nil {
+				handler(frame)
+			}
+		case line == "PING":
+			nats.writeLock.Lock()
+			nats.writer.WriteString("PONG\r\n")
+			nats.writer.Flush()
+			nats.writeLock.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			nats.setErr(fmt.Errorf("bridge: NATS %s", line))
+		}
+	}
+}
+
+func (nats *NATS) currentHandler() func(frame []byte) {
+	nats.handlerLock.Lock()
+	defer nats.handlerLock.Unlock()
+
+	return nats.handler
+}
+
+// Read the payload of a "MSG <subject> <sid> [reply-to] <#bytes>" message
+func (nats *NATS) readMsg(line string) ([]byte, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("bridge: malformed NATS message %q", line)
+	}
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("bridge: malformed NATS message %q", line)
+	}
+
+	payload := make([]byte, size+2)
+	if _, err := io.ReadFull(nats.reader, payload); err != nil {
+		return nil, err
+	}
+	return payload[:size], nil
+}
+
+func (nats *NATS) readLine() (string, error) {
+	line, err := nats.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,232 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisOption configures a Redis bridge
+type RedisOption func(*Redis)
+
+// RedisPassword authenticates with AUTH after connecting
+func RedisPassword(password string) RedisOption {
+	return func(redis *Redis) {
+		redis.password = password
+	}
+}
+
+// Redis is a Bridge publishing frames to a single Redis Pub/Sub channel. It
+// keeps one connection for publishing and, once subscribed, a second one in
+// subscriber mode. Like NATS it does not reconnect
+type Redis struct {
+	addr     string
+	channel  string
+	password string
+
+	pubLock sync.Mutex
+	pub     *redisConn
+
+	subLock sync.Mutex
+	sub     *redisConn
+	done    chan struct{}
+	closed  bool
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+// DialRedis connects to the Redis server at addr, exchanging frames on
+// channel
+func DialRedis(addr string, channel string, options ...RedisOption) (*Redis, error) {
+	redis := &Redis{addr: addr, channel: channel}
+	for _, option := range options {
+		option(redis)
+	}
+
+	pub, err := redis.dial()
+	if err != nil {
+		return nil, err
+	}
+	redis.pub = pub
+
+	return redis, nil
+}
+
+func (redis *Redis) dial() (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", redis.addr, DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+	if redis.password != "" {
+		if _, err := rc.do("AUTH", []byte(redis.password)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+func (redis *Redis) Publish(frame []byte) error {
+	redis.pubLock.Lock()
+	defer redis.pubLock.Unlock()
+
+	_, err := redis.pub.do("PUBLISH", []byte(redis.channel), frame)
+	return err
+}
+
+func (redis *Redis) Subscribe(handler func(frame []byte)) error {
+	redis.subLock.Lock()
+	defer redis.subLock.Unlock()
+
+	if redis.closed {
+		return ErrBridgeClosed
+	}
+	if redis.sub != nil {
+		return ErrAlreadySubscribed
+	}
+	sub, err := redis.dial()
+	if err != nil {
+		return err
+	}
+	if _, err := sub.do("SUBSCRIBE", []byte(redis.channel)); err != nil {
+		sub.conn.Close()
+		return err
+	}
+	redis.sub = sub
+	redis.done = make(chan struct{})
+	go redis.readLoop(sub, handler)
+
+	return nil
+}
+
+func (redis *Redis) Close() error {
+	redis.subLock.Lock()
+	redis.closed = true
+	sub, done := redis.sub, redis.done
+	redis.subLock.Unlock()
+
+	if sub != nil {
+		sub.conn.Close()
+		<-done
+	}
+
+	redis.pubLock.Lock()
+	defer redis.pubLock.Unlock()
+
+	return redis.pub.conn.Close()
+}
+
+func (redis *Redis) readLoop(sub *redisConn, handler func(frame []byte)) {
+	defer close(redis.done)
+
+	for {
+		reply, err := sub.readReply()
+		if err != nil {
+			return
+		}
+		// ["message", channel, payload]
+		message, ok := reply.([]interface{})
+		if !ok || len(message) != 3 {
+			continue
+		}
+		if kind, _ := message[0].([]byte); string(kind) != "message" {
+			continue
+		}
+		if frame, ok := message[2].([]byte); ok {
+			handler(frame)
+		}
+	}
+}
+
+// Send a command and read its reply
+func (rc *redisConn) do(args ...interface{}) (interface{}, error) {
+	rc.conn.SetDeadline(time.Now().Add(DialTimeout))
+	defer rc.conn.SetDeadline(time.Time{})
+
+	fmt.Fprintf(rc.writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var value []byte
+		switch arg := arg.(type) {
+		case string:
+			value = []byte(arg)
+		case []byte:
+			value = arg
+		}
+		fmt.Fprintf(rc.writer, "$%d\r\n", len(value))
+		rc.writer.Write(value)
+		rc.writer.WriteString("\r\n")
+	}
+	if err := rc.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return rc.readReply()
+}
+
+// Read a single RESP value. Bulk strings are returned as []byte, arrays as
+// []interface{} and errors as error
+func (rc *redisConn) readReply() (interface{}, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, errors.New("bridge: malformed Redis reply")
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("bridge: Redis %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(rc.reader, value); err != nil {
+			return nil, err
+		}
+		return value[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, count)
+		for i := range values {
+			if values[i], err = rc.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	return nil, errors.New("bridge: malformed Redis reply")
+}
@@ -0,0 +1,55 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// The event of the probe frames built by selfTest
+const selfTestEvent = "notify.selftest.bridge"
+
+// Check the bridge's connection, if it reports one, and push a probe through
+// the link's encoding, compression, encryption and signing and back without
+// touching the bus
+func (link *Link) selfTest(ctx context.Context) error {
+	if bridge, ok := link.bridge.(interface{ Err() error }); ok {
+		if err := bridge.Err(); err != nil {
+			return err
+		}
+	}
+
+	event := selfTestEvent
+	for _, exported := range link.exports {
+		if link.key(exported) != nil {
+			// Exercise the encryption too
+			event = exported
+			break
+		}
+	}
+
+	// Use every frame feature the link may, whatever its peers read
+	probe := map[string]interface{}{"probe": link.origin}
+	sent, err := link.newFrame(event, probe, ProtocolVersion, link.capabilities(), 0)
+	if err != nil {
+		return err
+	}
+	received, err := decodeFrame(sent.encode())
+	if err != nil {
+		return err
+	}
+	if received.origin != link.origin || received.event != event || !bytes.Equal(received.payload, sent.payload) {
+		return ErrBadFrame
+	}
+	data, err := link.readFrame(received)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(data, probe) {
+		return fmt.Errorf("bridge: probe decoded as %v", data)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build !notifyminimal
+
+package bridge
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrBadSignature = errors.New("Missing or invalid bridge frame signature")
+
+// Signer signs the frames a link forwards and verifies the ones it imports
+type Signer interface {
+	Sign(message []byte) ([]byte, error)
+	Verify(message []byte, signature []byte) bool
+}
+
+// WithSigner signs every forwarded notification with signer and rejects
+// imported ones that aren't validly signed as ErrBadSignature (see
+// Quarantine). Hello and discovery frames are signed and checked too, so an
+// unauthenticated peer can't pose as a peer, list fake topics or draw
+// discovery answers. Every peer sharing the bus must then sign its frames
+func WithSigner(signer Signer) Option {
+	return func(link *Link) {
+		link.signer = signer
+	}
+}
+
+// Control frames signed by a link with a signer start with this byte in place
+// of a frame version, followed by the length prefixed signature of the
+// unsigned control frame that makes up the rest
+const signedControlFrame = 0x82
+
+// Publish a hello or discovery frame, signed if the link has a signer
+func (link *Link) publishControl(frame []byte) error {
+	if link.signer == nil {
+		return link.bridge.Publish(frame)
+	}
+	signature, err := link.signer.Sign(frame)
+	if err != nil {
+		return err
+	}
+	signed := make([]byte, 0, 1+binary.MaxVarintLen64+len(signature)+len(frame))
+	signed = append(signed, signedControlFrame)
+	signed = binary.AppendUvarint(signed, uint64(len(signature)))
+	signed = append(signed, signature...)
+	return link.bridge.Publish(append(signed, frame...))
+}
+
+// The control frame a signed control frame carries. Its signature is checked
+// if the link has a signer
+func (link *Link) verifyControl(frame []byte) ([]byte, error) {
+	rest := frame[1:]
+	n, size := binary.Uvarint(rest)
+	if size <= 0 || uint64(len(rest)-size) < n {
+		return nil, ErrBadFrame
+	}
+	signature, control := rest[size:size+int(n)], rest[size+int(n):]
+	if len(control) == 0 || control[0] == signedControlFrame {
+		return nil, ErrBadFrame
+	}
+	if link.signer != nil && !link.signer.Verify(control, signature) {
+		return nil, ErrBadSignature
+	}
+	return control, nil
+}
+
+type hmacSigner struct {
+	key []byte
+}
+
+// HMACSigner signs frames with HMAC-SHA256 under a key shared by every peer
+func HMACSigner(key []byte) Signer {
+	return hmacSigner{key: key}
+}
+
+func (signer hmacSigner) Sign(message []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write(message)
+	return mac.Sum(nil), nil
+}
+
+func (signer hmacSigner) Verify(message []byte, signature []byte) bool {
+	expected, _ := signer.Sign(message)
+	return hmac.Equal(expected, signature)
+}
+
+type ed25519Signer struct {
+	private ed25519.PrivateKey
+	peers   []ed25519.PublicKey
+}
+
+// Ed25519Signer signs frames with private and accepts frames signed by any of
+// the peers' keys or its own, so a peer can't forge frames without its own
+// key being trusted. private may be nil for links that only import, which then
+// neither announce themselves to their peers nor list their topics
+func Ed25519Signer(private ed25519.PrivateKey, peers ...ed25519.PublicKey) Signer {
+	if private != nil {
+		peers = append(peers[:len(peers):len(peers)], private.Public().(ed25519.PublicKey))
+	}
+	return ed25519Signer{private: private, peers: peers}
+}
+
+func (signer ed25519Signer) Sign(message []byte) ([]byte, error) {
+	if signer.private == nil {
+		return nil, ErrBadSignature
+	}
+	return ed25519.Sign(signer.private, message), nil
+}
+
+func (signer ed25519Signer) Verify(message []byte, signature []byte) bool {
+	for _, peer := range signer.peers {
+		if ed25519.Verify(peer, message, signature) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+//go:build !notifyminimal
+
+package bridge_test
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/jesus-ramos/go-notify/bridge"
+)
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public, private
+}
+
+func TestLinkSigned(t *testing.T) {
+	exporterPublic, exporterPrivate := newKey(t)
+	for _, test := range []struct {
+		name               string
+		exporter, importer bridge.Signer
+	}{
+		{name: "hmac", exporter: bridge.HMACSigner([]byte("key")), importer: bridge.HMACSigner([]byte("key"))},
+		{name: "ed25519", exporter: bridge.Ed25519Signer(exporterPrivate), importer: bridge.Ed25519Signer(nil, exporterPublic)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			exporter, received := pair(t, &memoryBus{}, "orders",
+				[]bridge.Option{bridge.WithSigner(test.exporter)},
+				[]bridge.Option{bridge.WithSigner(test.importer)})
+
+			exporter.Post("orders", "order 1")
+			if got := receive(t, received); got != "order 1" {
+				t.Fatalf("imported %v, want order 1", got)
+			}
+		})
+	}
+}
+
+func TestLinkRejectsBadSignatures(t *testing.T) {
+	_, exporterPrivate := newKey(t)
+	otherPublic, _ := newKey(t)
+	hmac := bridge.HMACSigner([]byte("key"))
+	for _, test := range []struct {
+		name               string
+		exporter, importer bridge.Signer // nil if unsigned
+		tamper             func(frame []byte) []byte
+	}{
+		{name: "unsigned", importer: hmac},
+		{name: "wrong hmac key", exporter: bridge.HMACSigner([]byte("other key")), importer: hmac},
+		{name: "untrusted ed25519 key", exporter: bridge.Ed25519Signer(exporterPrivate), importer: bridge.Ed25519Signer(nil, otherPublic)},
+		{name: "ed25519 to hmac", exporter: bridge.Ed25519Signer(exporterPrivate), importer: hmac},
+		{name: "hmac to ed25519", exporter: hmac, importer: bridge.Ed25519Signer(nil, otherPublic)},
+		{name: "tampered payload", exporter: hmac, importer: hmac, tamper: func(frame []byte) []byte {
+			frame[len(frame)-2] ^= 1
+			return frame
+		}},
+		{name: "signature stripped", exporter: hmac, importer: hmac, tamper: func(frame []byte) []byte {
+			// Rewrite the flagged frame with an empty signature
+			origin := int(frame[1])
+			event := int(frame[2+origin])
+			at := 3 + origin + event
+			signature := int(frame[at])
+			return append(append(frame[:at:at], 0), frame[at+1+signature:]...)
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bus := &memoryBus{}
+			errs := make(errorLog, 16)
+			var exportOptions []bridge.Option
+			if test.exporter != nil {
+				exportOptions = append(exportOptions, bridge.WithSigner(test.exporter))
+			}
+			exporter, received := pair(t, bus, "orders", exportOptions,
+				[]bridge.Option{bridge.WithSigner(test.importer), errs.handler()})
+
+			if test.tamper != nil {
+				bus.tamperNotifications(test.tamper)
+			}
+			exporter.Post("orders", "order 1")
+			errs.expect(t, bridge.ErrBadSignature)
+			nothing(t, received)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of a Notifier, replaceable with WithClock so
+// tests and simulations (ie: backtests or game loops) can control it. It is
+// used for timestamps, post timeouts and deadlines, rate limits, schedules,
+// time events, batching, retries and event TTLs
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a time.Timer created by a Clock
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a time.Ticker created by a Clock
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock used unless WithClock is given
+var SystemClock Clock = systemClock{}
+
+// WithClock makes the notifier tell time with clock, ie: notifytest.FakeClock
+func WithClock(clock Clock) Option {
+	return func(notifier *Notifier) {
+		notifier.clock = clock
+	}
+}
+
+// Clock returns the clock the notifier tells time with, so producers posting
+// into it (ie: package sources) can follow the same time
+func (notifier *Notifier) Clock() Clock {
+	if notifier == nil {
+		return SystemClock
+	}
+	return notifier.clock
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (timer systemTimer) C() <-chan time.Time {
+	return timer.Timer.C
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (ticker systemTicker) C() <-chan time.Time {
+	return ticker.Ticker.C
+}
+
+type clockKey struct{}
+
+// ClockFromContext returns the clock of the notifier that called the Handler
+// ctx was handed to, so middleware tells the same time as the notifier. It is
+// SystemClock for any other context
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return clock
+	}
+	return SystemClock
+}
+
+// Like context.WithDeadline, with the deadline measured by clock
+func withClockDeadline(parent context.Context, clock Clock, deadline time.Time) (context.Context, context.CancelFunc) {
+	if clock == SystemClock {
+		return context.WithDeadline(parent, deadline)
+	}
+
+	ctx := &clockContext{Context: parent, deadline: deadline, done: make(chan struct{})}
+	timer := clock.NewTimer(deadline.Sub(clock.Now()))
+	go func() {
+		defer timer.Stop()
+
+		select {
+		case <-timer.C():
+			ctx.cancel(context.DeadlineExceeded)
+		case <-parent.Done():
+			ctx.cancel(parent.Err())
+		case <-ctx.done:
+		}
+	}()
+	return ctx, func() { ctx.cancel(context.Canceled) }
+}
+
+// A context expiring at a deadline told by a Clock other than the system's
+type clockContext struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+
+	sync.Mutex
+	err error
+}
+
+func (ctx *clockContext) Deadline() (time.Time, bool) {
+	return ctx.deadline, true
+}
+
+func (ctx *clockContext) Done() <-chan struct{} {
+	return ctx.done
+}
+
+func (ctx *clockContext) Err() error {
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	return ctx.err
+}
+
+func (ctx *clockContext) cancel(err error) {
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	if ctx.err == nil {
+		ctx.err = err
+		close(ctx.done)
+	}
+}
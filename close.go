@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotifierClosed = errors.New("Notifier closed")
+
+// ClosePolicy controls what happens to posts racing with or following Close
+type ClosePolicy int
+
+const (
+	// Reject them with ErrNotifierClosed (default)
+	CloseReject ClosePolicy = iota
+	// Keep delivering posts while Close waits, up to the drain timeout, for
+	// the posts in progress to finish, then reject them
+	CloseDrain
+	// Append them to the journal (see WithJournal), whether or not their
+	// event is journaled, so they can be replayed once the application
+	// restarts. They are rejected if there is no journal
+	CloseJournal
+)
+
+// Close states, see Notifier.closeState
+const (
+	notifierOpen int32 = iota
+	notifierDraining
+	notifierClosed
+)
+
+// WithClosePolicy sets what happens to posts racing with or following Close.
+// drain bounds how long Close waits for posts in progress under CloseDrain
+func WithClosePolicy(policy ClosePolicy, drain time.Duration) Option {
+	return func(notifier *Notifier) {
+		notifier.closePolicy = policy
+		notifier.closeDrain = drain
+	}
+}
+
+// Whether a post to event may go ahead. Posts once the notifier is closing
+// are handled according to its close policy, whose result is returned
+func (notifier *Notifier) accepting(event string, data interface{}) (bool, error) {
+	if notifier.admits() {
+		return true, nil
+	}
+	return false, notifier.closedPost(event, data)
+}
+
+// Whether posts may go ahead rather than being handled by the close policy
+func (notifier *Notifier) admits() bool {
+	switch notifier.closeState.Load() {
+	case notifierOpen:
+		return true
+	case notifierDraining:
+		return notifier.closePolicy == CloseDrain
+	}
+	return false
+}
+
+// Handle a post that isn't admitted according to the close policy
+func (notifier *Notifier) closedPost(event string, data interface{}) error {
+	if notifier.closePolicy == CloseJournal && notifier.journal != nil {
+		payload, err := notifier.journalCodec.Marshal(data)
+		if err != nil {
+			return err
+		}
+		return notifier.journal.Append(JournalEntry{Event: event, Posted: notifier.clock.Now(), Payload: payload})
+	}
+	return ErrNotifierClosed
+}
+
+// Count a post in progress, for drain
+func (notifier *Notifier) enterPost() {
+	notifier.inFlightLock.Lock()
+	defer notifier.inFlightLock.Unlock()
+
+	notifier.inFlight++
+}
+
+// Count a post as finished, letting drain know if it was the last one
+func (notifier *Notifier) leavePost() {
+	notifier.inFlightLock.Lock()
+	defer notifier.inFlightLock.Unlock()
+
+	notifier.inFlight--
+	if notifier.inFlight == 0 && notifier.drained != nil {
+		close(notifier.drained)
+		notifier.drained = nil
+	}
+}
+
+// Wait for the posts in progress to finish, up to the drain timeout
+func (notifier *Notifier) drain() {
+	notifier.inFlightLock.Lock()
+	if notifier.inFlight == 0 {
+		notifier.inFlightLock.Unlock()
+		return
+	}
+	drained := make(chan struct{})
+	notifier.drained = drained
+	notifier.inFlightLock.Unlock()
+
+	timer := notifier.clock.NewTimer(notifier.closeDrain)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+	case <-timer.C():
+	}
+}
+
+// Close stops every observer, closing the output channels it owns, cancels
+// all schedules and background pruning, stops mirroring (see WithMirror),
+// dropping the notifications still queued for the recorder, removes the
+// notifier from the registry and waits for callback observers to finish the
+// notifications already queued for them. Posts from then on are handled according to the
+// close policy (see WithClosePolicy)
+func (notifier *Notifier) Close() error {
+	if notifier.closePolicy == CloseDrain && notifier.closeState.CompareAndSwap(notifierOpen, notifierDraining) {
+		notifier.drain()
+	}
+	notifier.closeState.Store(notifierClosed)
+
+	notifier.Lock()
+	if notifier.closed {
+		notifier.Unlock()
+		return nil
+	}
+	notifier.closed = true
+	deregisterAll(notifier)
+	if notifier.pruneStop != nil {
+		close(notifier.pruneStop)
+	}
+	for i := range notifier.shards {
+		shard := &notifier.shards[i]
+		shard.Lock()
+		for event, entry := range shard.events {
+			if subs := entry.observers(); len(subs) > 0 {
+				closeObservers(subs, nil)
+				notifier.observed(event, false)
+			}
+			entry.setObservers(nil)
+			notifier.removeEntry(shard, event, entry)
+			notifier.stopTicker(event)
+		}
+		shard.Unlock()
+	}
+	notifier.Unlock()
+
+	notifier.scheduleLock.Lock()
+	for event, s := range notifier.schedules {
+		close(s.stop)
+		delete(notifier.schedules, event)
+	}
+	notifier.scheduleLock.Unlock()
+
+	notifier.stopMirror()
+	notifier.handlers.Wait()
+	return nil
+}
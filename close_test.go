@@ -0,0 +1,82 @@
+package notify_test
+
+import (
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+func TestPostGenerateDataCloseReject(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithClosePolicy(notify.CloseReject, 0))
+	notifier.Start("orders", make(chan interface{}, 1))
+	notifier.Close()
+
+	generated := false
+	err := notifier.PostGenerateData("orders", nil, func(interface{}) (interface{}, error) {
+		generated = true
+		return 1, nil
+	})
+	if err != notify.ErrNotifierClosed {
+		t.Fatalf("post after Close returned %v, want ErrNotifierClosed", err)
+	}
+	if generated {
+		t.Fatal("generator ran for a rejected post")
+	}
+}
+
+func TestPostGenerateDataCloseDrain(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithClosePolicy(notify.CloseDrain, time.Minute))
+	stalled := make(chan interface{})
+	notifier.Start("orders", stalled)
+
+	posted := make(chan error)
+	go func() {
+		posted <- notifier.PostGenerateData("orders", nil, constant(1))
+	}()
+	// Wait for the post to be blocked on the stalled observer
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		notifier.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the post in progress finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if got := <-stalled; got != 1 {
+		t.Fatalf("observer got %v, want 1", got)
+	}
+	if err := <-posted; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close still draining once the post finished")
+	}
+	if err := notifier.PostGenerateData("orders", nil, constant(2)); err != notify.ErrNotifierClosed {
+		t.Fatalf("post after Close returned %v, want ErrNotifierClosed", err)
+	}
+}
+
+func TestPostGenerateDataCloseJournal(t *testing.T) {
+	journal := &memJournal{}
+	notifier := notify.NewNotifier(
+		notify.WithJournal(journal, stringCodec{}),
+		notify.WithClosePolicy(notify.CloseJournal, 0),
+	)
+	notifier.Start("orders", make(chan interface{}, 1))
+	notifier.Close()
+
+	if err := notifier.PostGenerateData("orders", nil, constant("late")); err != nil {
+		t.Fatal(err)
+	}
+	if events := journal.events(); len(events) != 1 || events[0] != "orders=late" {
+		t.Fatalf("journaled %v, want the post made after Close", events)
+	}
+}
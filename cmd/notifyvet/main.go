@@ -0,0 +1,160 @@
+// Command notifyvet reports uses of deprecated notify APIs, so code can be
+// migrated to the Subscription based API incrementally.
+//
+// Usage:
+//
+//	notifyvet [packages]
+//
+// Packages are directories, optionally ending in /... to include every
+// directory below them, and default to ./... . Findings are printed like go
+// vet's, one per line as file:line:column: message, and make notifyvet exit
+// with status 1.
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const notifyPath = "github.com/jesus-ramos/go-notify"
+
+// Deprecated functions by types.Func.FullName, with what to use instead
+var deprecated = map[string]string{
+	"(*" + notifyPath + ".Notifier).Stop":      "use Unsubscribe on the Subscription returned by Start",
+	"(*" + notifyPath + ".Notifier).StopMulti": "use Unsubscribe on the Subscriptions returned by StartMulti",
+	"(*" + notifyPath + ".Notifier).Legacy":    "migrate callers to the Subscription API and drop the adapter",
+	notifyPath + ".Stop":                       "use Unsubscribe on the Subscription returned by Start",
+	notifyPath + ".StopMulti":                  "use Unsubscribe on the Subscriptions returned by StartMulti",
+}
+
+type finding struct {
+	pos     token.Position
+	message string
+}
+
+func main() {
+	patterns := os.Args[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	dirs, err := expand(patterns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "notifyvet:", err)
+		os.Exit(2)
+	}
+
+	var findings []finding
+	fset := token.NewFileSet()
+	for _, dir := range dirs {
+		found, err := checkDir(fset, dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "notifyvet:", err)
+			os.Exit(2)
+		}
+		findings = append(findings, found...)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i].pos, findings[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	for _, f := range findings {
+		fmt.Printf("%s: %s\n", f.pos, f.message)
+	}
+	if len(findings) > 0 {
+		os.Exit(1)
+	}
+}
+
+// The directories named by patterns
+func expand(patterns []string) ([]string, error) {
+	var dirs []string
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(pattern, "/...")
+		if !recursive {
+			dirs = append(dirs, pattern)
+			continue
+		}
+		if root == "" {
+			root = "."
+		}
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			name := entry.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+// Type check every package in dir and report its uses of deprecated APIs.
+// Type errors are ignored so partially broken code is still checked
+func checkDir(fset *token.FileSet, dir string) ([]finding, error) {
+	packages, err := parser.ParseDir(fset, dir, func(info fs.FileInfo) bool {
+		return strings.HasSuffix(info.Name(), ".go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []finding
+	for _, pkg := range packages {
+		files := make([]*ast.File, 0, len(pkg.Files))
+		for _, file := range pkg.Files {
+			files = append(files, file)
+		}
+
+		info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+		config := types.Config{
+			Importer: importer.ForCompiler(fset, "source", nil),
+			Error:    func(error) {},
+		}
+		config.Check(pkg.Name, fset, files, info)
+
+		for _, file := range files {
+			ast.Inspect(file, func(node ast.Node) bool {
+				selector, ok := node.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				fn, ok := info.Uses[selector.Sel].(*types.Func)
+				if !ok {
+					return true
+				}
+				if advice, ok := deprecated[fn.FullName()]; ok {
+					findings = append(findings, finding{
+						pos:     fset.Position(selector.Sel.Pos()),
+						message: fmt.Sprintf("%s is deprecated: %s", fn.Name(), advice),
+					})
+				}
+				return true
+			})
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckDir(t *testing.T) {
+	findings, err := checkDir(token.NewFileSet(), filepath.Join("testdata", "legacy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, fmt.Sprintf("%s:%d:%d: %s", filepath.Base(f.pos.Filename), f.pos.Line, f.pos.Column, f.message))
+	}
+	want := []string{
+		"legacy.go:10:11: Stop is deprecated: use Unsubscribe on the Subscription returned by Start",
+		"legacy.go:11:11: StopMulti is deprecated: use Unsubscribe on the Subscriptions returned by StartMulti",
+		"legacy.go:12:9: Stop is deprecated: use Unsubscribe on the Subscription returned by Start",
+		"legacy.go:13:11: Legacy is deprecated: migrate callers to the Subscription API and drop the adapter",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("found\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dirs, err := expand([]string{"./...", "elsewhere"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{".", "elsewhere"}; !reflect.DeepEqual(dirs, want) {
+		t.Fatalf("expanded to %v, want %v, skipping testdata", dirs, want)
+	}
+}
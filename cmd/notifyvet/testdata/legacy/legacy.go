@@ -0,0 +1,14 @@
+package legacy
+
+import notify "github.com/jesus-ramos/go-notify"
+
+func run(notifier *notify.Notifier, outputChan chan interface{}) {
+	subscription := notifier.Start("orders", outputChan)
+	subscription.Unsubscribe()
+	notifier.StopAll("orders")
+
+	notifier.Stop("orders", outputChan)
+	notifier.StopMulti([]string{"orders"}, outputChan)
+	notify.Stop("orders", outputChan)
+	notifier.Legacy()
+}
@@ -0,0 +1,37 @@
+package notify
+
+// Codec serializes notifications, either for transport (see the bridge and
+// httpgw packages) or to snapshot payloads at post time (see WithCopyOnPost)
+type Codec interface {
+	Marshal(data interface{}) ([]byte, error)
+	Unmarshal(payload []byte) (interface{}, error)
+}
+
+// WithCopyOnPost serializes every notification with codec when it is posted
+// and hands each observer its own decoded copy, so observers can't race on a
+// shared mutable payload (ie: a pointer to a struct) or see changes the
+// producer makes after posting. Use a codec that preserves types, such as
+// GobCodec, if observers rely on them. An observer whose copy fails to decode
+// misses the notification, which still reaches the others; the failure is
+// reported to it (see WithErrors) and counted in Stats
+func WithCopyOnPost(codec Codec) Option {
+	return func(notifier *Notifier) {
+		notifier.copyCodec = codec
+	}
+}
+
+// Serialize data for copy on post, returning nil if the notifier doesn't copy
+func (notifier *Notifier) snapshot(data interface{}) ([]byte, error) {
+	if notifier.copyCodec == nil {
+		return nil, nil
+	}
+	return notifier.copyCodec.Marshal(data)
+}
+
+// A fresh copy of the data a snapshot was taken of
+func (notifier *Notifier) restore(payload []byte, data interface{}) (interface{}, error) {
+	if notifier.copyCodec == nil {
+		return data, nil
+	}
+	return notifier.copyCodec.Unmarshal(payload)
+}
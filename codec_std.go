@@ -0,0 +1,45 @@
+//go:build !notifyminimal
+
+package notify
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// JSONCodec encodes notifications as JSON. Decoding produces the generic
+// representation (ie: map[string]interface{} for structs)
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func (JSONCodec) Unmarshal(payload []byte) (interface{}, error) {
+	var data interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GobCodec encodes notifications with encoding/gob, preserving their concrete
+// types. Every type sent must be registered with gob.Register on both sides
+type GobCodec struct{}
+
+func (GobCodec) Marshal(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(payload []byte) (interface{}, error) {
+	var data interface{}
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
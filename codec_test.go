@@ -0,0 +1,77 @@
+package notify_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+var errUndecodable = errors.New("undecodable")
+
+// failingCodec fails to decode the nth payload it is handed
+type failingCodec struct {
+	stringCodec
+	n     int32
+	calls atomic.Int32
+}
+
+func (codec *failingCodec) Unmarshal(payload []byte) (interface{}, error) {
+	if codec.calls.Add(1) == codec.n {
+		return nil, errUndecodable
+	}
+	return codec.stringCodec.Unmarshal(payload)
+}
+
+func TestCopyOnPost(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithCopyOnPost(stringCodec{}))
+	defer notifier.Close()
+
+	received, _ := notifier.StartOwned("orders", 1)
+	if err := notifier.Post("orders", "order 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received; got != "decoded order 1" {
+		t.Fatalf("observer got %v, want its own copy", got)
+	}
+}
+
+func TestCopyOnPostFailureSkipsOneObserver(t *testing.T) {
+	// The post decodes its first copy itself, each observer the next ones
+	notifier := notify.NewNotifier(notify.WithCopyOnPost(&failingCodec{n: 2}))
+	defer notifier.Close()
+
+	first, firstSub := notifier.StartOwned("orders", 1, notify.WithErrors(1))
+	second, secondSub := notifier.StartOwned("orders", 1, notify.WithErrors(1))
+	if err := notifier.Post("orders", "order 1"); err != nil {
+		t.Fatalf("post returned %v, want the failure reported to the observer only", err)
+	}
+
+	delivered, failed := 0, 0
+	for _, observer := range []struct {
+		received <-chan interface{}
+		errs     <-chan error
+	}{{first, firstSub.Errors()}, {second, secondSub.Errors()}} {
+		select {
+		case got := <-observer.received:
+			if got != "decoded order 1" {
+				t.Fatalf("observer got %v, want its own copy", got)
+			}
+			delivered++
+		case err := <-observer.errs:
+			if !errors.Is(err, errUndecodable) {
+				t.Fatalf("observer told %v, want the decoding error", err)
+			}
+			failed++
+		default:
+			t.Fatal("observer got neither the notification nor the failure")
+		}
+	}
+	if delivered != 1 || failed != 1 {
+		t.Fatalf("%d observers got the notification and %d the failure, want 1 each", delivered, failed)
+	}
+	if stats := notifier.Stats(); stats.CopyFailed != 1 {
+		t.Fatalf("counted %d failed copies, want 1", stats.CopyFailed)
+	}
+}
@@ -0,0 +1,84 @@
+package notify
+
+import "sync"
+
+// TopicConcurrency declares whether the Handlers observing an event may run
+// at the same time
+type TopicConcurrency int
+
+const (
+	// Handlers run in parallel as their subscribe options allow. This is the
+	// default
+	TopicConcurrent TopicConcurrency = iota
+	// One Handler invocation at a time across every observer of the event
+	TopicSerial
+	// One Handler invocation at a time per key, invocations for different
+	// keys running in parallel
+	TopicPerKey
+)
+
+// WithTopicConcurrency declares how the Handlers observing event (see
+// StartFunc) may run, so handlers sharing state that isn't safe for
+// concurrent use don't need their own locking. With TopicPerKey, key maps
+// each notification to the key it is serialized on; a nil key serializes the
+// whole event. Output channels are read by their owners and aren't affected.
+// Handlers of a serialized event must not post to it and wait for delivery,
+// since the observers they'd wait for can't run until they return
+func WithTopicConcurrency(event string, mode TopicConcurrency, key func(data interface{}) string) Option {
+	return func(notifier *Notifier) {
+		if mode == TopicConcurrent {
+			delete(notifier.topics, event)
+			return
+		}
+		if key == nil {
+			mode = TopicSerial
+		}
+		notifier.topics[event] = &topicLock{mode: mode, key: key, keys: make(map[string]*keyLock)}
+	}
+}
+
+// Serializes the Handler invocations of a topic
+type topicLock struct {
+	mode TopicConcurrency
+	key  func(data interface{}) string
+
+	serial   sync.Mutex
+	keysLock sync.Mutex
+	keys     map[string]*keyLock
+}
+
+// A lock on one key, removed once nobody holds or waits for it
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// Wait for the invocation of a Handler with data to be allowed to run,
+// returning the function releasing it
+func (lock *topicLock) acquire(data interface{}) func() {
+	if lock.mode == TopicSerial {
+		lock.serial.Lock()
+		return lock.serial.Unlock
+	}
+
+	key := lock.key(data)
+	lock.keysLock.Lock()
+	held, ok := lock.keys[key]
+	if !ok {
+		held = &keyLock{}
+		lock.keys[key] = held
+	}
+	held.refs++
+	lock.keysLock.Unlock()
+
+	held.Lock()
+	return func() {
+		held.Unlock()
+
+		lock.keysLock.Lock()
+		if held.refs--; held.refs == 0 {
+			delete(lock.keys, key)
+		}
+		lock.keysLock.Unlock()
+	}
+}
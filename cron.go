@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCronSpec = errors.New("Invalid cron expression")
+
+// A parsed cron expression. Each field is a bit set of the values it matches
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+	location                      *time.Location
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse a standard five field cron expression (minute hour day-of-month month
+// day-of-week) or one of the @yearly, @monthly, @weekly, @daily and @hourly
+// descriptors. The expression may be prefixed with TZ=<zone> or
+// CRON_TZ=<zone> to evaluate it in that time zone instead of location
+func parseCron(spec string, location *time.Location) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		i := strings.IndexByte(spec, ' ')
+		if i < 0 {
+			return nil, ErrInvalidCronSpec
+		}
+		zone := spec[strings.IndexByte(spec, '=')+1 : i]
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCronSpec, err)
+		}
+		location = loc
+		spec = strings.TrimSpace(spec[i:])
+	}
+	if descriptor, ok := cronDescriptors[spec]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidCronSpec, len(fields))
+	}
+
+	schedule := &cronSchedule{location: location}
+	var err error
+	if schedule.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if schedule.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if schedule.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// 7 is an alias for Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	schedule.domStar = fields[2] == "*" || fields[2] == "?"
+	schedule.dowStar = fields[4] == "*" || fields[4] == "?"
+
+	return schedule, nil
+}
+
+// Parse a comma separated list of values, ranges and steps into a bit set
+func (field cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w: bad step in %q", ErrInvalidCronSpec, part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		low, high := field.min, field.max
+		switch {
+		case part == "*" || part == "?":
+		case strings.IndexByte(part, '-') > 0:
+			i := strings.IndexByte(part, '-')
+			var err error
+			if low, err = field.value(part[:i]); err != nil {
+				return 0, err
+			}
+			if high, err = field.value(part[i+1:]); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := field.value(part)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if step == 1 {
+				high = value
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("%w: bad range %q", ErrInvalidCronSpec, part)
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+func (field cronField) value(s string) (int, error) {
+	if value, ok := field.names[strings.ToLower(s)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil || value < field.min || value > field.max {
+		return 0, fmt.Errorf("%w: value %q out of range", ErrInvalidCronSpec, s)
+	}
+	return value, nil
+}
+
+func (schedule *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := schedule.dom&(1<<uint(t.Day())) != 0
+	dowMatch := schedule.dow&(1<<uint(t.Weekday())) != 0
+	if schedule.domStar || schedule.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Returns the first occurrence strictly after t, or the zero time if the
+// expression can't be satisfied (ie: "0 0 30 2 *"). Times skipped when the
+// clocks go forward never occur, and times repeated when they go back only
+// occur the first time
+func (schedule *cronSchedule) next(t time.Time) time.Time {
+	t = t.In(schedule.location).Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+	// Step through absolute time so daylight saving changes can't send t back
+	for t.Year() <= yearLimit {
+		switch {
+		case schedule.month&(1<<uint(t.Month())) == 0:
+			t = schedule.midnight(t.Year(), t.Month()+1, 1)
+		case !schedule.dayMatches(t):
+			t = schedule.midnight(t.Year(), t.Month(), t.Day()+1)
+		case schedule.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case schedule.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			if end, ok := repeated(t); ok {
+				t = end
+				continue
+			}
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// The first instant of a day, which isn't 00:00 in zones whose clocks go
+// forward at midnight
+func (schedule *cronSchedule) midnight(year int, month time.Month, day int) time.Time {
+	noon := time.Date(year, month, day, 12, 0, 0, 0, schedule.location)
+	t := noon.Add(-12 * time.Hour)
+	for t.Day() != noon.Day() {
+		t = t.Add(time.Minute)
+	}
+	for t.Add(-time.Minute).Day() == noon.Day() {
+		t = t.Add(-time.Minute)
+	}
+	return t
+}
+
+// Whether the local time of t already happened before the clocks last went
+// back, and if so when that repeated stretch ends
+func repeated(t time.Time) (time.Time, bool) {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	_, before := start.Add(-time.Second).Zone()
+	_, offset := t.Zone()
+	back := time.Duration(before-offset) * time.Second
+	if back <= 0 || t.Sub(start) >= back {
+		return time.Time{}, false
+	}
+	return start.Add(back), true
+}
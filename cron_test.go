@@ -0,0 +1,120 @@
+package notify_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	return loc
+}
+
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestScheduleNextRun(t *testing.T) {
+	wednesday := at(2026, time.October, 14, 10, 7)
+	for _, test := range []struct {
+		spec string
+		zone string // UTC if empty
+		from time.Time
+		want time.Time // zero if never
+	}{
+		{spec: "*/15 * * * *", from: wednesday, want: at(2026, time.October, 14, 10, 15)},
+		{spec: "5-10 * * * *", from: wednesday, want: at(2026, time.October, 14, 10, 8)},
+		{spec: "5-10 * * * *", from: at(2026, time.October, 14, 10, 10), want: at(2026, time.October, 14, 11, 5)},
+		{spec: "0 9-17/4 * * *", from: wednesday, want: at(2026, time.October, 14, 13, 0)},
+		{spec: "30 */6 * * *", from: wednesday, want: at(2026, time.October, 14, 12, 30)},
+		{spec: "0 0,12 * * *", from: wednesday, want: at(2026, time.October, 14, 12, 0)},
+		{spec: "0 0 * jan,jul *", from: wednesday, want: at(2027, time.January, 1, 0, 0)},
+		{spec: "0 0 * * MON-FRI", from: wednesday, want: at(2026, time.October, 15, 0, 0)},
+		{spec: "0 0 * * 7", from: wednesday, want: at(2026, time.October, 18, 0, 0)},
+		{spec: "@monthly", from: wednesday, want: at(2026, time.November, 1, 0, 0)},
+		{spec: "@hourly", from: wednesday, want: at(2026, time.October, 14, 11, 0)},
+		// Day of month and day of week match either way unless one is *
+		{spec: "0 0 13 * fri", from: wednesday, want: at(2026, time.October, 16, 0, 0)},
+		{spec: "0 0 13 * *", from: wednesday, want: at(2026, time.November, 13, 0, 0)},
+		{spec: "0 0 * * fri", from: wednesday, want: at(2026, time.October, 16, 0, 0)},
+		{spec: "0 0 13 * ?", from: wednesday, want: at(2026, time.November, 13, 0, 0)},
+		// Month and year ends
+		{spec: "0 0 31 * *", from: wednesday, want: at(2026, time.October, 31, 0, 0)},
+		{spec: "0 0 31 * *", from: at(2026, time.November, 1, 0, 0), want: at(2026, time.December, 31, 0, 0)},
+		{spec: "0 0 29 2 *", from: wednesday, want: at(2028, time.February, 29, 0, 0)},
+		{spec: "59 23 31 12 *", from: at(2026, time.December, 31, 23, 59), want: at(2027, time.December, 31, 23, 59)},
+		{spec: "0 0 30 2 *", from: wednesday},
+		// Time zones, given with InLocation or a prefix
+		{spec: "0 9 * * *", zone: "America/New_York", from: wednesday, want: at(2026, time.October, 14, 13, 0)},
+		{spec: "TZ=America/New_York 0 9 * * *", from: wednesday, want: at(2026, time.October, 14, 13, 0)},
+		{spec: "CRON_TZ=Asia/Kolkata 0 * * * *", from: wednesday, want: at(2026, time.October, 14, 10, 30)},
+		// 02:30 doesn't exist the day clocks go forward
+		{spec: "30 2 * * *", zone: "America/New_York", from: at(2026, time.March, 7, 17, 0), want: at(2026, time.March, 9, 6, 30)},
+		{spec: "0 3 * * *", zone: "America/New_York", from: at(2026, time.March, 8, 6, 0), want: at(2026, time.March, 8, 7, 0)},
+		// 01:00 to 02:00 happens twice the day they go back, and only fires once
+		{spec: "30 1 * * *", zone: "America/New_York", from: at(2026, time.November, 1, 4, 0), want: at(2026, time.November, 1, 5, 30)},
+		{spec: "30 1 * * *", zone: "America/New_York", from: at(2026, time.November, 1, 5, 30), want: at(2026, time.November, 2, 6, 30)},
+		{spec: "*/20 * * * *", zone: "America/New_York", from: at(2026, time.November, 1, 5, 40), want: at(2026, time.November, 1, 7, 0)},
+		{spec: "*/20 * * * *", zone: "America/New_York", from: at(2026, time.November, 1, 6, 10), want: at(2026, time.November, 1, 7, 0)},
+	} {
+		t.Run(test.spec, func(t *testing.T) {
+			loc := time.UTC
+			if test.zone != "" {
+				loc = loadLocation(t, test.zone)
+			}
+			notifier := notify.NewNotifier(notify.WithClock(notifytest.NewFakeClock(test.from)))
+			defer notifier.Close()
+
+			if err := notifier.Schedule("tick", test.spec, notify.InLocation(loc)); err != nil {
+				t.Fatal(err)
+			}
+			next, ok := notifier.NextRun("tick")
+			switch {
+			case test.want.IsZero() && ok:
+				t.Fatalf("next run %v, want none", next)
+			case !test.want.IsZero() && !next.Equal(test.want):
+				t.Fatalf("next run after %v is %v, want %v", test.from, next.UTC(), test.want)
+			}
+		})
+	}
+}
+
+func TestScheduleInvalidSpec(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"10-5 * * * *",
+		"1-2-3 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"* * * * someday",
+		"@fortnightly",
+		"TZ=UTC",
+		"TZ=Nowhere/City * * * * *",
+	} {
+		if err := notifier.Schedule("tick", spec); !errors.Is(err, notify.ErrInvalidCronSpec) {
+			t.Errorf("Schedule(%q) returned %v, want ErrInvalidCronSpec", spec, err)
+			notifier.Unschedule("tick")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+//go:build !notifyminimal
+
+// Package debug serves runtime controls for investigating a notifier in
+// production over HTTP:
+//
+//	http.Handle("/debug/notify", debug.New(notifier, debug.WithAuth(auth)))
+//
+// Sampling captures the notifications of a single event into a bounded ring
+// buffer (see notify.Notifier.StartSampling) without permanent logging:
+//
+//	POST   /debug/notify?event=orders&rate=10&size=500&for=5m   start sampling
+//	GET    /debug/notify?event=orders                           download the samples
+//	DELETE /debug/notify?event=orders                           stop and download the samples
+//	GET    /debug/notify                                        list the events being sampled
+//
+// Samples are downloaded one JSON object {"time": ..., "data": ...} per line,
+// the data being encoded with the configured codec.
+//
+// The notifier's counters (see notify.Stats) can be scraped without a metrics
+// client, as JSON or as text/plain lines, both following the versioned schema
+// of notify.Stats.MarshalJSON:
+//
+//	GET    /debug/notify?stats                                  the counters as JSON
+//	GET    /debug/notify?stats=text                             the counters as text
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithAuth rejects requests for which auth returns an error with 401
+// Unauthorized. Nothing is checked by default, so the handler should only be
+// reachable by operators
+func WithAuth(auth func(r *http.Request) error) Option {
+	return func(handler *Handler) {
+		handler.auth = auth
+	}
+}
+
+// WithCodec sets the codec used to encode sampled notifications
+// (notify.JSONCodec by default). Its output is embedded in the JSON of each
+// sample, as a string unless it is JSON itself
+func WithCodec(codec notify.Codec) Option {
+	return func(handler *Handler) {
+		handler.codec = codec
+	}
+}
+
+// Handler is an http.Handler controlling a notifier's sampling and serving its
+// stats
+type Handler struct {
+	notifier *notify.Notifier
+	auth     func(r *http.Request) error
+	codec    notify.Codec
+}
+
+// New returns a debug handler for notifier
+func New(notifier *notify.Notifier, options ...Option) *Handler {
+	handler := &Handler{notifier: notifier, codec: notify.JSONCodec{}}
+	for _, option := range options {
+		option(handler)
+	}
+	return handler
+}
+
+// A sample as downloaded
+type sample struct {
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler.auth != nil {
+		if err := handler.auth(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	query := r.URL.Query()
+	event := query.Get("event")
+	switch {
+	case r.Method == http.MethodGet && query.Has("stats"):
+		handler.writeStats(w, query.Get("stats"))
+	case r.Method == http.MethodGet && event == "":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handler.notifier.Sampling())
+	case r.Method == http.MethodGet:
+		samples, ok := handler.notifier.Samples(event)
+		handler.writeSamples(w, samples, ok)
+	case r.Method == http.MethodDelete:
+		samples, ok := handler.notifier.StopSampling(event)
+		handler.writeSamples(w, samples, ok)
+	case r.Method == http.MethodPost:
+		config, err := sampleConfig(query)
+		if err == nil && event == "" {
+			err = errors.New("No event to sample")
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := handler.notifier.StartSampling(event, config); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// The sampling bounds requested by the rate, size and for query parameters
+func sampleConfig(query url.Values) (notify.SampleConfig, error) {
+	var config notify.SampleConfig
+	var err error
+	if value := query.Get("rate"); value != "" {
+		if config.Rate, err = strconv.ParseFloat(value, 64); err != nil {
+			return config, err
+		}
+	}
+	if value := query.Get("size"); value != "" {
+		if config.Size, err = strconv.Atoi(value); err != nil {
+			return config, err
+		}
+	}
+	if value := query.Get("for"); value != "" {
+		if config.Duration, err = time.ParseDuration(value); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
+
+func (handler *Handler) writeStats(w http.ResponseWriter, format string) {
+	stats := handler.notifier.Stats()
+	switch format {
+	case "", "json":
+		data, _ := stats.MarshalJSON()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(data, '\n'))
+	case "text":
+		data, _ := stats.MarshalText()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(data)
+	default:
+		http.Error(w, "Unknown stats format", http.StatusBadRequest)
+	}
+}
+
+func (handler *Handler) writeSamples(w http.ResponseWriter, samples []notify.Sample, ok bool) {
+	if !ok {
+		http.Error(w, "Event not sampled", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for _, s := range samples {
+		data, err := handler.codec.Marshal(s.Data)
+		if err != nil {
+			data, _ = json.Marshal(err.Error())
+		} else if !json.Valid(data) {
+			data, _ = json.Marshal(string(data))
+		}
+		if err := encoder.Encode(sample{Time: s.Time, Data: bytes.TrimSpace(data)}); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,129 @@
+//go:build !notifyminimal
+
+package debug_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/debug"
+)
+
+func serve(handler http.Handler, method string, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestSampling(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	handler := debug.New(notifier)
+
+	if w := serve(handler, http.MethodPost, "/debug/notify?event=orders&size=2"); w.Code != http.StatusCreated {
+		t.Fatalf("start sampling answered %d: %s", w.Code, w.Body)
+	}
+	if w := serve(handler, http.MethodPost, "/debug/notify?event=orders"); w.Code != http.StatusConflict {
+		t.Fatalf("sampling again answered %d, want 409", w.Code)
+	}
+	w := serve(handler, http.MethodGet, "/debug/notify")
+	if !strings.Contains(w.Body.String(), `"Event":"orders"`) {
+		t.Fatalf("listed %s, want orders", w.Body)
+	}
+
+	for _, data := range []interface{}{map[string]int{"id": 1}, "two", 3} {
+		notifier.Post("orders", data)
+	}
+	w = serve(handler, http.MethodGet, "/debug/notify?event=orders")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("samples served as %s", ct)
+	}
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], `"data":"two"}`) || !strings.HasSuffix(lines[1], `"data":3}`) {
+		t.Fatalf("downloaded %q, want the newest 2 samples", lines)
+	}
+
+	if w := serve(handler, http.MethodDelete, "/debug/notify?event=orders"); strings.Count(w.Body.String(), "\n") != 2 {
+		t.Fatalf("stopping returned %q, want the 2 samples", w.Body)
+	}
+	if w := serve(handler, http.MethodGet, "/debug/notify?event=orders"); w.Code != http.StatusNotFound {
+		t.Fatalf("samples of a stopped event answered %d, want 404", w.Code)
+	}
+}
+
+func TestSamplingBadRequest(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	handler := debug.New(notifier)
+
+	for _, target := range []string{
+		"/debug/notify",
+		"/debug/notify?event=orders&rate=fast",
+		"/debug/notify?event=orders&size=big",
+		"/debug/notify?event=orders&for=ever",
+	} {
+		if w := serve(handler, http.MethodPost, target); w.Code != http.StatusBadRequest {
+			t.Errorf("POST %s answered %d, want 400", target, w.Code)
+		}
+	}
+	if w := serve(handler, http.MethodPut, "/debug/notify"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT answered %d, want 405", w.Code)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	handler := debug.New(notifier, debug.WithAuth(func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "operator" {
+			return errors.New("Not an operator")
+		}
+		return nil
+	}))
+
+	if w := serve(handler, http.MethodPost, "/debug/notify?event=orders"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated request answered %d, want 401", w.Code)
+	}
+	if _, ok := notifier.Samples("orders"); ok {
+		t.Fatal("unauthenticated request started sampling")
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/debug/notify?event=orders", nil)
+	r.Header.Set("Authorization", "operator")
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("authenticated request answered %d, want 201", w.Code)
+	}
+}
+
+func TestStats(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	handler := debug.New(notifier)
+	notifier.Post("orders", 1)
+
+	for _, test := range []struct {
+		query       string
+		contentType string
+		body        string
+	}{
+		{"stats", "application/json", `{"version":1,"unobserved":1,`},
+		{"stats=json", "application/json", `{"version":1,"unobserved":1,`},
+		{"stats=text", "text/plain; charset=utf-8", "version 1\nunobserved 1\n"},
+	} {
+		w := serve(handler, http.MethodGet, "/debug/notify?"+test.query)
+		if ct := w.Header().Get("Content-Type"); ct != test.contentType {
+			t.Errorf("?%s served as %s, want %s", test.query, ct, test.contentType)
+		}
+		if !strings.HasPrefix(w.Body.String(), test.body) {
+			t.Errorf("?%s served %q, want it to start with %q", test.query, w.Body, test.body)
+		}
+	}
+	if w := serve(handler, http.MethodGet, "/debug/notify?stats=xml"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown stats format answered %d, want 400", w.Code)
+	}
+}
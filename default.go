@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+var defaultNotifier atomic.Pointer[Notifier]
+
+func init() {
+	defaultNotifier.Store(NewNotifier())
+}
+
+// Default returns the process wide notifier used by the package level
+// functions
+func Default() *Notifier {
+	return defaultNotifier.Load()
+}
+
+// SetDefault replaces the process wide notifier. Observers started on the
+// previous default are left untouched
+func SetDefault(notifier *Notifier) {
+	defaultNotifier.Store(notifier)
+}
+
+// Start observing the specified event on the default notifier
+func Start(event string, outputChan chan interface{}, options ...SubscribeOption) *Subscription {
+	return Default().Start(event, outputChan, options...)
+}
+
+// Start observing the specified event on the default notifier via a channel
+// it owns
+func StartOwned(event string, buffer int, options ...SubscribeOption) (<-chan interface{}, *Subscription) {
+	return Default().StartOwned(event, buffer, options...)
+}
+
+// Stop observing the specified event on the default notifier
+//
+// Deprecated: use Unsubscribe on the Subscription returned by Start
+func Stop(event string, outputChan chan interface{}) error {
+	return Default().Stop(event, outputChan)
+}
+
+// Stop observing the specified event on all channels of the default notifier
+func StopAll(event string) error {
+	return Default().StopAll(event)
+}
+
+// Post a notification to the specified event on the default notifier
+func Post(event string, data interface{}) error {
+	return Default().Post(event, data)
+}
+
+// Post a notification to the specified event on the default notifier using
+// the provided timeout for any output channels that are blocking
+func PostTimeout(event string, data interface{}, timeout time.Duration) error {
+	return Default().PostTimeout(event, data, timeout)
+}
+
+// Post a notification to the specified event on the default notifier, giving
+// up on output channels that are still blocking once ctx is done
+func PostContext(ctx context.Context, event string, data interface{}) error {
+	return Default().PostContext(ctx, event, data)
+}
+
+// Post a notification to the specified event on the default notifier and wait
+// for the handlers it was delivered to to return
+func PostWait(event string, data interface{}) error {
+	return Default().PostWait(event, data)
+}
+
+// Post a notification to the specified event on the default notifier using a
+// function to generate the data
+func PostGenerateData(event string, state interface{}, generator func(s interface{}) (interface{}, error)) error {
+	return Default().PostGenerateData(event, state, generator)
+}
+
+// Post err to the specified event on the default notifier wrapped in an
+// ErrorEvent. Nothing is posted if err is nil
+func PostError(event string, err error) error {
+	if err == nil {
+		return nil
+	}
+	notifier := Default()
+	errEvent := newErrorEvent(err, notifier.clock.Now())
+	return notifier.PostSeverity(event, errEvent.Severity, errEvent)
+}
+
+// Post a notification to the specified event on the default notifier at the
+// provided severity
+func PostSeverity(event string, severity Severity, data interface{}) error {
+	return Default().PostSeverity(event, severity, data)
+}
+
+// Observe every listed event on outputChan on the default notifier
+func StartMulti(events []string, outputChan chan interface{}, options ...SubscribeOption) []*Subscription {
+	return Default().StartMulti(events, outputChan, options...)
+}
+
+// Stop observing every listed event on outputChan on the default notifier
+//
+// Deprecated: use Unsubscribe on the Subscriptions returned by StartMulti
+func StopMulti(events []string, outputChan chan interface{}) error {
+	return Default().StopMulti(events, outputChan)
+}
+
+// Make old another name for event on the default notifier
+func Alias(old string, event string) error {
+	return Default().Alias(old, event)
+}
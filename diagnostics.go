@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DiagnosticKind identifies the kind of misuse a Diagnostic reports
+type DiagnosticKind int
+
+const (
+	// A Handler posted to the event it is handling, which deadlocks unless
+	// the handler's queue has room (see WithConcurrency) or dispatch is
+	// synchronous, where it recurses
+	DiagnosticReentrantPost DiagnosticKind = iota
+	// A post has been waiting on a single observer for longer than the slow
+	// subscriber threshold
+	DiagnosticSlowSubscriber
+	// The same channel was started on an event it already observes, so it
+	// receives every notification twice
+	DiagnosticDuplicateStart
+)
+
+func (kind DiagnosticKind) String() string {
+	switch kind {
+	case DiagnosticReentrantPost:
+		return "reentrant post"
+	case DiagnosticSlowSubscriber:
+		return "slow subscriber"
+	case DiagnosticDuplicateStart:
+		return "duplicate start"
+	}
+	return "DiagnosticKind(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// Diagnostic is a misuse found by WithDiagnostics
+type Diagnostic struct {
+	Kind  DiagnosticKind
+	Event string
+	// How long the post had been waiting, for DiagnosticSlowSubscriber
+	Blocked time.Duration
+	// The stack of the offending goroutine, or of every goroutine for
+	// DiagnosticSlowSubscriber since the observer's can't be told apart
+	Stack string
+}
+
+// WithDiagnostics checks for common misuse and reports what it finds to hook:
+// handlers posting to their own event, posts blocked on a single observer for
+// longer than slowThreshold (zero disables the check) and channels started
+// twice on the same event. The checks slow posts and deliveries down and are
+// meant for development and staging
+func WithDiagnostics(hook func(finding Diagnostic), slowThreshold time.Duration) Option {
+	return func(notifier *Notifier) {
+		notifier.diagnostics = &diagnostics{
+			hook:          hook,
+			slowThreshold: slowThreshold,
+			dispatching:   make(map[dispatchKey]int),
+		}
+	}
+}
+
+type diagnostics struct {
+	hook          func(finding Diagnostic)
+	slowThreshold time.Duration
+
+	// Handlers running, by event and goroutine
+	dispatching map[dispatchKey]int
+	sync.Mutex
+}
+
+type dispatchKey struct {
+	event     string
+	goroutine uint64
+}
+
+// Mark the calling goroutine as handling event until the returned function is
+// called
+func (diag *diagnostics) dispatch(event string) func() {
+	key := dispatchKey{event: event, goroutine: goroutineID()}
+	diag.Lock()
+	diag.dispatching[key]++
+	diag.Unlock()
+
+	return func() {
+		diag.Lock()
+		defer diag.Unlock()
+
+		if diag.dispatching[key]--; diag.dispatching[key] == 0 {
+			delete(diag.dispatching, key)
+		}
+	}
+}
+
+// Report a post to event made while the calling goroutine is handling it
+func (diag *diagnostics) checkPost(event string) {
+	diag.Lock()
+	reentrant := diag.dispatching[dispatchKey{event: event, goroutine: goroutineID()}] > 0
+	diag.Unlock()
+
+	if reentrant {
+		diag.hook(Diagnostic{Kind: DiagnosticReentrantPost, Event: event, Stack: stack(false)})
+	}
+}
+
+// Report starting an observer on a channel already observing the event. Must
+// be called with the event's shard locked
+func (diag *diagnostics) checkStart(event string, subs []*subscriber, sub *subscriber) {
+	if sub.handler != nil {
+		return
+	}
+	for _, existing := range subs {
+		if existing.outputChan == sub.outputChan {
+			// Reported without the shard's lock held
+			go diag.hook(Diagnostic{Kind: DiagnosticDuplicateStart, Event: event, Stack: stack(false)})
+			return
+		}
+	}
+}
+
+// Run send, reporting the post if it waits longer than the slow subscriber
+// threshold as told by clock
+func (diag *diagnostics) watchSend(event string, clock Clock, send func() bool) bool {
+	if diag.slowThreshold <= 0 {
+		return send()
+	}
+	timer := clock.NewTimer(diag.slowThreshold)
+	sent := make(chan struct{})
+	go func() {
+		select {
+		case <-sent:
+		case <-timer.C():
+			diag.hook(Diagnostic{
+				Kind:    DiagnosticSlowSubscriber,
+				Event:   event,
+				Blocked: diag.slowThreshold,
+				Stack:   stack(true),
+			})
+		}
+	}()
+	defer func() {
+		timer.Stop()
+		close(sent)
+	}()
+
+	return send()
+}
+
+// The stack of the calling goroutine or of every goroutine
+func stack(all bool) string {
+	buf := make([]byte, 16<<10)
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// The runtime's id of the calling goroutine, parsed from its stack trace
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+var ErrInvalidInterval = errors.New("Invalid digest interval")
+
+// Digest summarizes the notifications posted to an event over an interval,
+// as posted by StartDigest
+type Digest struct {
+	Event string
+	Start time.Time // the interval summarized
+	End   time.Time
+	Count int
+	First time.Time // when the first and last notifications were posted,
+	Last  time.Time // zero if there were none
+	// Samples are notifications picked uniformly at random among those
+	// posted during the interval
+	Samples []interface{}
+}
+
+// DigestEvent names the event digests of event are posted to, ie:
+// "orders.digest"
+func DigestEvent(event string) string {
+	return event + ".digest"
+}
+
+// StartDigest observes event and posts a Digest of its notifications, with up
+// to samples of them, to DigestEvent(event) every interval, including
+// intervals in which nothing was posted. A last digest of the partial
+// interval is posted once the returned Subscription is stopped. A non-positive
+// interval fails the Subscription with ErrInvalidInterval
+func (notifier *Notifier) StartDigest(event string, interval time.Duration, samples int, options ...SubscribeOption) *Subscription {
+	if interval <= 0 {
+		return failedSubscription(event, fmt.Errorf("%w: %v", ErrInvalidInterval, interval))
+	}
+	component := "digest " + event
+	input, subscription := notifier.StartOwned(event, 0, append(options, WithEnvelopes(), WithComponent(component))...)
+	if subscription.Err() != nil {
+		return subscription
+	}
+	unregister := notifier.addFlow(component, DigestEvent(event))
+
+	go func() {
+		defer unregister()
+		notifier.runDigest(event, interval, samples, input)
+	}()
+	return subscription
+}
+
+func (notifier *Notifier) runDigest(event string, interval time.Duration, samples int, input <-chan interface{}) {
+	target := DigestEvent(event)
+	ticker := notifier.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	digest := Digest{Event: event, Start: notifier.clock.Now()}
+	flush := func() {
+		digest.End = notifier.clock.Now()
+		if err := notifier.Post(target, digest); err != nil && err != ErrEventNotFound && err != ErrNotifierClosed {
+			notifier.reportError(target, err)
+		}
+		digest = Digest{Event: event, Start: digest.End}
+	}
+
+	for {
+		select {
+		case value, ok := <-input:
+			if !ok {
+				flush()
+				return
+			}
+			envelope, _ := value.(Envelope)
+			if digest.Count == 0 {
+				digest.First = envelope.Posted
+			}
+			digest.Last = envelope.Posted
+			digest.Count++
+			// Reservoir sampling keeps every notification equally likely to
+			// be picked without knowing how many are coming
+			if len(digest.Samples) < samples {
+				digest.Samples = append(digest.Samples, envelope.Data)
+			} else if i := rand.IntN(digest.Count); i < samples {
+				digest.Samples[i] = envelope.Data
+			}
+		case <-ticker.C():
+			flush()
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrorEvent is the notification delivered for errors posted with PostError
+type ErrorEvent struct {
+	Err      error
+	Severity Severity
+	// Messages of Err and every error it wraps, depth first
+	Chain []string
+	// Call stack of the goroutine that posted the error
+	Stack  string
+	Posted time.Time
+}
+
+func (event *ErrorEvent) Error() string {
+	return event.Err.Error()
+}
+
+func (event *ErrorEvent) Unwrap() error {
+	return event.Err
+}
+
+// An error carrying its severity
+type severityError struct {
+	error
+	severity Severity
+}
+
+func (err severityError) Unwrap() error {
+	return err.error
+}
+
+// WithSeverity annotates err so PostError reports it at severity instead of
+// SeverityError
+func WithSeverity(err error, severity Severity) error {
+	if err == nil {
+		return nil
+	}
+	return severityError{err, severity}
+}
+
+// SeverityOf returns the severity err was annotated with, SeverityError if
+// none
+func SeverityOf(err error) Severity {
+	var annotated severityError
+	if errors.As(err, &annotated) {
+		return annotated.severity
+	}
+	return SeverityError
+}
+
+// Post err to the specified event wrapped in an ErrorEvent recording its
+// chain, severity and the stack of the caller. Nothing is posted if err is nil
+func (notifier *Notifier) PostError(event string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if notifier == nil {
+		return ErrNilNotifier
+	}
+	errEvent := newErrorEvent(err, notifier.clock.Now())
+	return notifier.PostSeverity(event, errEvent.Severity, errEvent)
+}
+
+// Build the ErrorEvent for err posted at now, capturing the stack of the
+// caller of the function calling newErrorEvent
+func newErrorEvent(err error, now time.Time) *ErrorEvent {
+	return &ErrorEvent{
+		Err:      err,
+		Severity: SeverityOf(err),
+		Chain:    errorChain(err, nil),
+		Stack:    callers(4),
+		Posted:   now,
+	}
+}
+
+func errorChain(err error, chain []string) []string {
+	if err == nil {
+		return chain
+	}
+	if _, ok := err.(severityError); !ok {
+		chain = append(chain, err.Error())
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return errorChain(wrapped.Unwrap(), chain)
+	case interface{ Unwrap() []error }:
+		for _, inner := range wrapped.Unwrap() {
+			chain = errorChain(inner, chain)
+		}
+	}
+	return chain
+}
+
+// Format the stack, omitting the innermost skip frames
+func callers(skip int) string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip, pcs)])
+
+	var stack strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&stack, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			return stack.String()
+		}
+	}
+}
+
+// AsErrorEvent returns the ErrorEvent delivered as data, if it is one
+func AsErrorEvent(data interface{}) (*ErrorEvent, bool) {
+	event, ok := data.(*ErrorEvent)
+	return event, ok
+}
+
+// ErrorFrom returns the error posted with PostError that was delivered as
+// data, or nil if data isn't one
+func ErrorFrom(data interface{}) error {
+	if event, ok := AsErrorEvent(data); ok {
+		return event.Err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// WithExecutionTrace annotates the notifier's work for runtime/trace, so
+// event flow shows up in Go execution traces (see go tool trace): every post
+// runs as a "notify.Post" task logging its event, with a "notify.deliver"
+// region around handing it to observers, and every Handler call runs in a
+// "notify.handle" region of the task of the post it handles. Nothing is
+// recorded, and next to nothing spent, unless a trace is being taken
+func WithExecutionTrace() Option {
+	return func(notifier *Notifier) {
+		notifier.execTrace = true
+	}
+}
+
+// Start the task of a post to event, returning its context and a function
+// ending it
+func (notifier *Notifier) startTask(ctx context.Context, event string) (context.Context, func()) {
+	if !notifier.execTrace || !trace.IsEnabled() {
+		return ctx, func() {}
+	}
+	ctx, task := trace.NewTask(ctx, "notify.Post")
+	trace.Log(ctx, "event", event)
+	return ctx, task.End
+}
+
+// Start a region of ctx's task, returning a function ending it
+func (notifier *Notifier) startRegion(ctx context.Context, name string) func() {
+	if !notifier.execTrace || !trace.IsEnabled() {
+		return func() {}
+	}
+	return trace.StartRegion(ctx, name).End
+}
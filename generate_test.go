@@ -0,0 +1,150 @@
+package notify_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// stringCodec encodes string notifications as their bytes and decodes them
+// tagged, so tests see which values went through it
+type stringCodec struct{}
+
+func (stringCodec) Marshal(data interface{}) ([]byte, error) {
+	return []byte(data.(string)), nil
+}
+
+func (stringCodec) Unmarshal(payload []byte) (interface{}, error) {
+	return "decoded " + string(payload), nil
+}
+
+// memJournal keeps journal entries in memory
+type memJournal struct {
+	sync.Mutex
+	entries []notify.JournalEntry
+}
+
+func (journal *memJournal) Append(entry notify.JournalEntry) error {
+	journal.Lock()
+	defer journal.Unlock()
+
+	journal.entries = append(journal.entries, entry)
+	return nil
+}
+
+func (journal *memJournal) Replay(event string, from time.Time, fn func(entry notify.JournalEntry) error) error {
+	journal.Lock()
+	entries := append([]notify.JournalEntry(nil), journal.entries...)
+	journal.Unlock()
+
+	for _, entry := range entries {
+		if entry.Event != event || entry.Posted.Before(from) {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (journal *memJournal) events() []string {
+	journal.Lock()
+	defer journal.Unlock()
+
+	var events []string
+	for _, entry := range journal.entries {
+		events = append(events, entry.Event+"="+string(entry.Payload))
+	}
+	return events
+}
+
+func constant(value interface{}) func(interface{}) (interface{}, error) {
+	return func(interface{}) (interface{}, error) {
+		return value, nil
+	}
+}
+
+func TestPostGenerateDataSkipsUnrecordedEvents(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	generated := false
+	err := notifier.PostGenerateData("orders", nil, func(interface{}) (interface{}, error) {
+		generated = true
+		return 1, nil
+	})
+	if err != notify.ErrEventNotFound {
+		t.Fatalf("post without observers returned %v, want ErrEventNotFound", err)
+	}
+	if generated {
+		t.Fatal("generator ran for an event nothing records")
+	}
+}
+
+func TestPostGenerateDataStickyWithoutObservers(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithSticky("config"))
+	defer notifier.Close()
+
+	if err := notifier.PostGenerateData("config", nil, constant("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := notifier.StickyValue("config"); !ok || value != "v1" {
+		t.Fatalf("sticky value %v, %v, want v1", value, ok)
+	}
+}
+
+func TestPostGenerateDataBuffersWithoutObservers(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithNoSubscribers(notify.NoSubscribersBuffer))
+	defer notifier.Close()
+
+	if err := notifier.PostGenerateData("orders", nil, constant(1)); err != nil {
+		t.Fatal(err)
+	}
+	outputChan := make(chan interface{}, 1)
+	notifier.Start("orders", outputChan)
+	select {
+	case got := <-outputChan:
+		if got != 1 {
+			t.Fatalf("buffered %v, want 1", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("generated notification wasn't buffered")
+	}
+}
+
+func TestPostGenerateDataJournalsAndCopies(t *testing.T) {
+	journal := &memJournal{}
+	notifier := notify.NewNotifier(
+		notify.WithJournal(journal, stringCodec{}, "orders"),
+		notify.WithCopyOnPost(stringCodec{}),
+		notify.WithSticky("orders"),
+	)
+	defer notifier.Close()
+
+	first, second := make(chan interface{}, 1), make(chan interface{}, 1)
+	notifier.Start("orders", first)
+	notifier.Start("orders", second)
+	n := 0
+	err := notifier.PostGenerateData("orders", nil, func(interface{}) (interface{}, error) {
+		n++
+		return "order " + string(rune('0'+n)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-first; got != "decoded order 1" {
+		t.Fatalf("first observer got %v, want a copy of order 1", got)
+	}
+	if got := <-second; got != "decoded order 2" {
+		t.Fatalf("second observer got %v, want a copy of order 2", got)
+	}
+	if events := journal.events(); len(events) != 1 || events[0] != "orders=decoded order 1" {
+		t.Fatalf("journaled %v, want the first value only", events)
+	}
+	if value, _ := notifier.StickyValue("orders"); value != "decoded order 1" {
+		t.Fatalf("sticky value %v, want a copy of the first value", value)
+	}
+}
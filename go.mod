@@ -0,0 +1,3 @@
+module github.com/jesus-ramos/go-notify
+
+go 1.24
@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ComponentLabel is the label naming the component an observer belongs to,
+// see WithComponent
+const ComponentLabel = "component"
+
+// WithComponent names the component the observer belongs to, so Graph can
+// show who listens to what
+func WithComponent(name string) SubscribeOption {
+	return WithLabel(ComponentLabel, name)
+}
+
+// GraphNode is an event or a component in a Graph
+type GraphNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"` // "event" or "component"
+	// Observers of the event, or the component's observers
+	Subscribers int `json:"subscribers"`
+}
+
+// GraphEdge connects two GraphNodes by ID
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// "observes" from an event to a component observing it, "posts" from a
+	// pipeline or digest to its destination and "alias" from an alias to its
+	// event
+	Kind string `json:"kind"`
+	// Observers of the event belonging to the component, for "observes"
+	Count int `json:"count,omitempty"`
+}
+
+// Graph is the topology of a notifier, see Notifier.Graph. It marshals to
+// JSON as is
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// A posting component registered by a pipeline or digest
+type flow struct {
+	component string
+	event     string
+}
+
+// Graph returns the current topology of the notifier: every started event,
+// the components observing them (see WithComponent; observers without a
+// component only count towards their event's subscribers), the pipelines and
+// digests posting to them and the aliases naming them. Nodes and edges are
+// sorted
+func (notifier *Notifier) Graph() Graph {
+	var graph Graph
+	components := make(map[string]int)
+	addEvent := func(event string, subscribers int) {
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: "event:" + event, Name: event, Kind: "event", Subscribers: subscribers})
+	}
+	events := make(map[string]bool)
+
+	for _, snapshot := range notifier.Snapshot() {
+		events[snapshot.Name] = true
+		addEvent(snapshot.Name, len(snapshot.Subscribers))
+
+		counts := make(map[string]int)
+		for _, sub := range snapshot.Subscribers {
+			if component := sub.Labels[ComponentLabel]; component != "" {
+				counts[component]++
+			}
+		}
+		for component, count := range counts {
+			components[component] += count
+			graph.Edges = append(graph.Edges, GraphEdge{From: "event:" + snapshot.Name, To: "component:" + component, Kind: "observes", Count: count})
+		}
+	}
+
+	notifier.flowLock.Lock()
+	for _, f := range notifier.flows {
+		if _, ok := components[f.component]; !ok {
+			components[f.component] = 0
+		}
+		if !events[f.event] {
+			events[f.event] = true
+			addEvent(f.event, 0)
+		}
+		graph.Edges = append(graph.Edges, GraphEdge{From: "component:" + f.component, To: "event:" + f.event, Kind: "posts"})
+	}
+	notifier.flowLock.Unlock()
+
+	if aliases := notifier.aliases.Load(); aliases != nil {
+		for alias, event := range *aliases {
+			for _, name := range []string{alias, event} {
+				if !events[name] {
+					events[name] = true
+					addEvent(name, 0)
+				}
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{From: "event:" + alias, To: "event:" + event, Kind: "alias"})
+		}
+	}
+
+	for component, subscribers := range components {
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: "component:" + component, Name: component, Kind: "component", Subscribers: subscribers})
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return graph
+}
+
+// DOT renders the graph in Graphviz's DOT language, events as boxes and
+// components as ellipses
+func (graph Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph notify {\n")
+	for _, node := range graph.Nodes {
+		shape := "ellipse"
+		if node.Kind == "event" {
+			shape = "box"
+		}
+		label := node.Name
+		if node.Subscribers > 0 {
+			label += " (" + strconv.Itoa(node.Subscribers) + ")"
+		}
+		b.WriteString("\t" + strconv.Quote(node.ID) + " [label=" + strconv.Quote(label) + " shape=" + shape + "];\n")
+	}
+	for _, edge := range graph.Edges {
+		label := edge.Kind
+		if edge.Count > 1 {
+			label += " x" + strconv.Itoa(edge.Count)
+		}
+		style := ""
+		if edge.Kind == "alias" {
+			style = " style=dashed"
+		}
+		b.WriteString("\t" + strconv.Quote(edge.From) + " -> " + strconv.Quote(edge.To) + " [label=" + strconv.Quote(label) + style + "];\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Register component as posting to event for Graph until the returned
+// function is called
+func (notifier *Notifier) addFlow(component string, event string) func() {
+	notifier.flowLock.Lock()
+	defer notifier.flowLock.Unlock()
+
+	notifier.flowID++
+	id := notifier.flowID
+	notifier.flows[id] = flow{component: component, event: event}
+
+	return func() {
+		notifier.flowLock.Lock()
+		defer notifier.flowLock.Unlock()
+
+		delete(notifier.flows, id)
+	}
+}
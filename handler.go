@@ -0,0 +1,271 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Handler is called with every notification posted to an event it observes.
+// ctx carries the notification's Envelope (see EnvelopeFromContext) and the
+// post's deadline, if any
+type Handler func(ctx context.Context, data interface{}) error
+
+// Envelope describes a posted notification
+type Envelope struct {
+	Event string
+	Seq   uint64 // per event sequence number, starting at 1
+	// GlobalSeq orders notifications across every event, starting at 1. It
+	// is zero unless the notifier was created WithGlobalSequence
+	GlobalSeq uint64
+	Posted    time.Time
+	Severity  Severity
+	Source    string // see WithSource
+	// Ctx is the context the notification was posted with (see PostContext),
+	// so long running observers can stop once the producer cancels it
+	Ctx context.Context
+	// Data is the notification itself when delivered to an output channel
+	// started WithEnvelopes
+	Data interface{}
+}
+
+// The Envelope of a notification posted with pc
+func (pc postContext) envelope(event string, rec record) Envelope {
+	return Envelope{
+		Event:     event,
+		Seq:       rec.seq,
+		GlobalSeq: rec.global,
+		Posted:    rec.posted,
+		Severity:  pc.severity,
+		Source:    pc.source,
+		Ctx:       pc.ctx,
+	}
+}
+
+// WithEnvelopes delivers every notification to the output channel wrapped in
+// an Envelope
+func WithEnvelopes() SubscribeOption {
+	return func(sub *subscriber) {
+		sub.envelopes = true
+	}
+}
+
+type envelopeKey struct{}
+
+type sourceKey struct{}
+
+// WithSource tags notifications posted with PostContext(ctx, ...) with the
+// producer's name, which observers find in their Envelope
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFromContext returns the source tag set by WithSource
+func SourceFromContext(ctx context.Context) string {
+	source, _ := ctx.Value(sourceKey{}).(string)
+	return source
+}
+
+// EnvelopeFromContext returns the Envelope of the notification a Handler was
+// called for
+func EnvelopeFromContext(ctx context.Context) (Envelope, bool) {
+	envelope, ok := ctx.Value(envelopeKey{}).(Envelope)
+	return envelope, ok
+}
+
+// WithErrorHandler receives errors returned by Handlers, which are otherwise
+// dropped
+func WithErrorHandler(handler func(event string, err error)) Option {
+	return func(notifier *Notifier) {
+		notifier.onError = handler
+	}
+}
+
+// Hand err to the error handler, if any
+func (notifier *Notifier) reportError(event string, err error) {
+	if notifier.onError != nil {
+		notifier.onError(event, err)
+	}
+}
+
+// A notification queued for a Handler
+type invocation struct {
+	postContext
+	envelope Envelope
+	data     interface{}
+}
+
+// The context handed to a Handler, which must be cancelled once it returns
+func (inv invocation) context(clock Clock) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(inv.ctx, envelopeKey{}, inv.envelope)
+	ctx = context.WithValue(ctx, clockKey{}, clock)
+	if inv.deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return withClockDeadline(ctx, clock, inv.deadline)
+}
+
+// WithConcurrency lets up to n invocations of the observer's Handler run in
+// parallel, with up to n more notifications queued before posts wait. Ordering
+// between notifications is lost; see WithOrdered. Events declared serial with
+// WithTopicConcurrency still run one invocation at a time
+func WithConcurrency(n int) SubscribeOption {
+	return func(sub *subscriber) {
+		sub.concurrency = n
+	}
+}
+
+// WithOrdered runs the observer's Handler one notification at a time in the
+// order they were posted, overriding WithConcurrency. This is the default
+func WithOrdered() SubscribeOption {
+	return func(sub *subscriber) {
+		sub.ordered = true
+	}
+}
+
+// StartFunc observes the specified event by calling handler with every
+// notification. By default handlers run one notification at a time on their
+// own goroutine and, like an unbuffered output channel, posts wait for a busy
+// handler. Pending invocations are drained by Close. Nothing is started for a
+// nil handler, in which case the Subscription's Err reports it
+func (notifier *Notifier) StartFunc(event string, handler Handler, options ...SubscribeOption) *Subscription {
+	switch {
+	case notifier == nil:
+		return failedSubscription(event, ErrNilNotifier)
+	case handler == nil:
+		return failedSubscription(event, ErrNilHandler)
+	}
+	sub := &subscriber{}
+	for _, option := range options {
+		option(sub)
+	}
+	sub.handler = chain(handler, sub.middleware)
+	sub.owned = true
+
+	workers := 1
+	switch {
+	case notifier.syncDispatch:
+		workers = 0
+		sub.outputChan = make(chan interface{})
+		sub.invoke = func(value interface{}) {
+			notifier.invoke(sub, value.(invocation))
+		}
+	case sub.concurrency > 1 && !sub.ordered:
+		workers = sub.concurrency
+		sub.outputChan = make(chan interface{}, workers)
+	default:
+		sub.outputChan = make(chan interface{})
+	}
+	notifier.handlers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go notifier.runHandler(sub)
+	}
+
+	shard, event := notifier.lockEvent(notifier.resolve(event))
+	defer notifier.unlockEvent(shard)
+
+	if err := notifier.admit(event, 1); err != nil {
+		close(sub.outputChan)
+		return failedSubscription(event, err)
+	}
+	notifier.start(event, sub)
+	return &Subscription{notifier: notifier, event: event, sub: sub}
+}
+
+func (notifier *Notifier) runHandler(sub *subscriber) {
+	defer notifier.handlers.Done()
+
+	for value := range sub.outputChan {
+		notifier.invoke(sub, value.(invocation))
+	}
+}
+
+func (notifier *Notifier) invoke(sub *subscriber, inv invocation) {
+	if notifier.diagnostics != nil {
+		defer notifier.diagnostics.dispatch(inv.envelope.Event)()
+	}
+	if lock, ok := notifier.topicLock(inv.envelope.Event); ok {
+		defer lock.acquire(inv.data)()
+	}
+	ctx, cancel := inv.context(notifier.clock)
+	endRegion := notifier.startRegion(ctx, "notify.handle")
+	err := sub.handler(ctx, inv.data)
+	endRegion()
+	cancel()
+
+	if inv.waiter != nil {
+		inv.waiter.finish(err)
+	}
+	if err != nil {
+		sub.report(inv.envelope.Event, inv.data, err)
+		notifier.reportError(inv.envelope.Event, err)
+	}
+}
+
+// WithSyncDispatch calls Handlers (see StartFunc) on the posting goroutine,
+// so Post returns only once every handler has, making tests deterministic
+// without sleeps. Middleware and other subscribe options still apply, but
+// WithConcurrency is ignored. Handlers may post and stop observers, though
+// posting to the event being handled recurses
+func WithSyncDispatch() Option {
+	return func(notifier *Notifier) {
+		notifier.syncDispatch = true
+	}
+}
+
+// PostWait posts a notification to the specified event like Post, then waits
+// for every Handler it was delivered to (see StartFunc) to return, so
+// producers of events such as "flush_caches" know the work is done. The errors
+// returned by the handlers are joined into the result. Output channels only
+// hold up PostWait until they receive the notification
+func (notifier *Notifier) PostWait(event string, data interface{}) error {
+	waiter := &postWaiter{}
+	pc := backgroundPost
+	pc.waiter = waiter
+	err := notifier.post(event, data, pc, func(deliveries []delivery) error {
+		for _, d := range deliveries {
+			if _, ok := d.value.(invocation); ok {
+				waiter.wg.Add(1)
+			}
+		}
+
+		workers := 1
+		if notifier.fanOutWorkers > 1 && len(deliveries) >= notifier.fanOutThreshold {
+			workers = notifier.fanOutWorkers
+		}
+		fanOut(workers, len(deliveries), func(i int) {
+			d := deliveries[i]
+			_, handled := d.value.(invocation)
+			if !notifier.send(d) && handled {
+				waiter.finish(nil)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return waiter.wait()
+}
+
+// Collects the results of the handlers a PostWait was delivered to
+type postWaiter struct {
+	wg   sync.WaitGroup
+	lock sync.Mutex
+	errs []error
+}
+
+func (waiter *postWaiter) finish(err error) {
+	if err != nil {
+		waiter.lock.Lock()
+		waiter.errs = append(waiter.errs, err)
+		waiter.lock.Unlock()
+	}
+	waiter.wg.Done()
+}
+
+func (waiter *postWaiter) wait() error {
+	waiter.wg.Wait()
+	return errors.Join(waiter.errs...)
+}
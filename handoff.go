@@ -0,0 +1,164 @@
+//go:build !notifyminimal
+
+package notify
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The state of a notifier handed over to the process replacing it
+type handoffState struct {
+	Epoch  int64                   `json:"epoch"`
+	Events map[string]handoffEvent `json:"events"`
+}
+
+type handoffEvent struct {
+	Seq     uint64          `json:"seq,omitempty"`
+	History []handoffRecord `json:"history,omitempty"`
+	Sticky  *handoffRecord  `json:"sticky,omitempty"`
+}
+
+type handoffRecord struct {
+	Seq     uint64    `json:"seq"`
+	Posted  time.Time `json:"posted"`
+	Payload []byte    `json:"payload"` // the notification encoded by the handoff codec
+}
+
+// ExportState encodes the sequence numbers, history and sticky notifications
+// of every event, with notifications encoded by codec, so a process replacing
+// this one during an upgrade can continue where it left off with ImportState.
+// Resume tokens issued by this notifier remain valid in the new one. Export
+// once posting has stopped so no notification is left out
+func (notifier *Notifier) ExportState(codec Codec) ([]byte, error) {
+	state := handoffState{Epoch: notifier.epoch, Events: make(map[string]handoffEvent)}
+
+	for i := range notifier.shards {
+		shard := &notifier.shards[i]
+		shard.RLock()
+		for event, entry := range shard.events {
+			exported, err := entry.export(codec)
+			if err != nil {
+				shard.RUnlock()
+				return nil, err
+			}
+			state.Events[event] = exported
+		}
+		shard.RUnlock()
+	}
+
+	notifier.stickyLock.Lock()
+	defer notifier.stickyLock.Unlock()
+
+	for event, value := range notifier.sticky {
+		rec, err := exportRecord(codec, value.rec)
+		if err != nil {
+			return nil, err
+		}
+		exported := state.Events[event]
+		exported.Sticky = &rec
+		state.Events[event] = exported
+	}
+
+	return json.Marshal(state)
+}
+
+func (entry *eventEntry) export(codec Codec) (handoffEvent, error) {
+	entry.Lock()
+	defer entry.Unlock()
+
+	exported := handoffEvent{Seq: entry.seq}
+	for _, rec := range entry.history {
+		encoded, err := exportRecord(codec, rec)
+		if err != nil {
+			return handoffEvent{}, err
+		}
+		exported.History = append(exported.History, encoded)
+	}
+	return exported, nil
+}
+
+func exportRecord(codec Codec, rec record) (handoffRecord, error) {
+	payload, err := codec.Marshal(rec.data)
+	if err != nil {
+		return handoffRecord{}, err
+	}
+	return handoffRecord{Seq: rec.seq, Posted: rec.posted, Payload: payload}, nil
+}
+
+// ImportState restores the state exported by the process being replaced. It
+// must be called before the notifier is used. Imported sticky notifications
+// take precedence over those restored from a StickyStore
+func (notifier *Notifier) ImportState(data []byte, codec Codec) error {
+	var state handoffState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	notifier.epoch = state.Epoch
+	for event, imported := range state.Events {
+		if imported.Sticky != nil {
+			rec, err := importRecord(codec, *imported.Sticky)
+			if err != nil {
+				return err
+			}
+			if err := notifier.fitSticky(event, rec.data); err != nil {
+				return err
+			}
+			snapshot, err := notifier.snapshot(rec.data)
+			if err != nil {
+				return err
+			}
+			notifier.keepSticky(event, rec, snapshot)
+		}
+		if imported.Seq == 0 {
+			continue
+		}
+		history := make([]record, 0, len(imported.History))
+		for _, encoded := range imported.History {
+			rec, err := importRecord(codec, encoded)
+			if err != nil {
+				return err
+			}
+			history = append(history, rec)
+		}
+		notifier.importEvent(event, imported.Seq, history)
+	}
+
+	return nil
+}
+
+func (notifier *Notifier) importEvent(event string, seq uint64, history []record) {
+	shard, event := notifier.lockEvent(event)
+	defer notifier.unlockEvent(shard)
+
+	entry, ok := shard.events[event]
+	if !ok {
+		entry = notifier.newEntry()
+		shard.events[event] = entry
+		notifier.eventCount.Add(1)
+	}
+
+	entry.Lock()
+	defer entry.Unlock()
+
+	entry.seq = seq
+	entry.discard(len(entry.history))
+	entry.history = history
+	if entry.memory != nil {
+		for i := range history {
+			history[i].size = entry.memory.size(history[i].data)
+			entry.retained += history[i].size
+			entry.memory.charge(history[i].size)
+		}
+		entry.trim()
+	}
+}
+
+func importRecord(codec Codec, encoded handoffRecord) (record, error) {
+	data, err := codec.Unmarshal(encoded.Payload)
+	if err != nil {
+		return record{}, err
+	}
+	return record{seq: encoded.Seq, posted: encoded.Posted, data: data}, nil
+}
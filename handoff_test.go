@@ -0,0 +1,59 @@
+//go:build !notifyminimal
+
+package notify_test
+
+import (
+	"testing"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+func TestStateHandoff(t *testing.T) {
+	old := historyNotifier(t, 10, notify.WithSticky("config"))
+	stream, err := old.Watch("orders", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postAll(old, "orders", "a", "b")
+	token := nextChange(t, stream).Token
+	nextChange(t, stream)
+	stream.Close()
+	if err := old.Post("config", "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := old.ExportState(stringCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement := notify.NewNotifier(notify.WithHistory(10), notify.WithSticky("config"))
+	defer replacement.Close()
+	if err := replacement.ImportState(state, stringCodec{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok := replacement.StickyValue("config"); !ok || value != "decoded v1" {
+		t.Fatalf("sticky value %v, %v, want the one handed over", value, ok)
+	}
+	resumed, err := replacement.Watch("orders", token)
+	if err != nil {
+		t.Fatalf("token issued before the handoff rejected: %v", err)
+	}
+	defer resumed.Close()
+	if got := nextChange(t, resumed).Data; got != "decoded b" {
+		t.Fatalf("resumed with %v, want the history handed over", got)
+	}
+	postAll(replacement, "orders", "c")
+	if got := nextChange(t, resumed).Data; got != "c" {
+		t.Fatalf("resumed stream got %v, want c", got)
+	}
+}
+
+func TestImportStateMalformed(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	if err := notifier.ImportState([]byte("{"), stringCodec{}); err == nil {
+		t.Fatal("malformed state imported")
+	}
+}
@@ -0,0 +1,283 @@
+//go:build !notifyminimal
+
+// Package httpgw relays notifications to browsers over WebSocket or
+// Server-Sent Events.
+//
+// Clients choose events with one or more event query parameters, which must
+// match the patterns the gateway allows:
+//
+//	http.Handle("/events", httpgw.New(notifier, httpgw.Allow("orders.*")))
+//
+//	// browser
+//	new EventSource("/events?event=orders.created&event=orders.shipped")
+//
+// Every notification is sent as a JSON object {"event": ..., "data": ...}
+// unless another codec is configured with WithCodec. Over SSE the event field
+// is also used as the SSE event type. Requests for events the notifier can't
+// observe, because it is closed or at one of its limits, are answered with
+// 503 Service Unavailable.
+//
+// In the other direction, Ingest accepts notifications posted by external
+// systems over plain HTTP (see NewIngest).
+package httpgw
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+var (
+	ErrNoEvents       = errors.New("No events requested")
+	ErrEventForbidden = errors.New("Event not allowed")
+)
+
+// DefaultBuffer is the number of notifications queued per connection
+const DefaultBuffer = 64
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// Allow lets clients observe events matching any of the patterns (see
+// path.Match). Nothing is allowed by default
+func Allow(patterns ...string) Option {
+	return func(handler *Handler) {
+		handler.allow = append(handler.allow, patterns...)
+	}
+}
+
+// WithAuth rejects requests for which auth returns an error with 401
+// Unauthorized. It is also given the events the client asked for
+func WithAuth(auth func(r *http.Request, events []string) error) Option {
+	return func(handler *Handler) {
+		handler.auth = auth
+	}
+}
+
+// WithBuffer sets the number of notifications queued for each connection
+// while the client catches up
+func WithBuffer(size int) Option {
+	return func(handler *Handler) {
+		handler.buffer = size
+	}
+}
+
+// DisconnectSlow closes connections whose queue fills up instead of dropping
+// the notifications that don't fit
+func DisconnectSlow() Option {
+	return func(handler *Handler) {
+		handler.disconnectSlow = true
+	}
+}
+
+// WithCodec sets the codec used to encode messages (notify.JSONCodec by
+// default). Over SSE its output must be text without line breaks
+func WithCodec(codec notify.Codec) Option {
+	return func(handler *Handler) {
+		handler.codec = codec
+	}
+}
+
+// WithHeartbeat sets how often idle connections are pinged so proxies don't
+// time them out (30 seconds by default, zero disables). Heartbeats and idle
+// timeouts are timed by the notifier's clock (see notify.WithClock)
+func WithHeartbeat(interval time.Duration) Option {
+	return func(handler *Handler) {
+		handler.heartbeat = interval
+	}
+}
+
+// WithIdleTimeout closes connections that haven't been sent a notification
+// for timeout, heartbeats aside, so abandoned clients don't hold observers
+// forever. Zero, the default, keeps idle connections open
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(handler *Handler) {
+		handler.idleTimeout = timeout
+	}
+}
+
+// Handler is an http.Handler relaying notifications to connected clients
+type Handler struct {
+	notifier       *notify.Notifier
+	allow          []string
+	auth           func(r *http.Request, events []string) error
+	buffer         int
+	disconnectSlow bool
+	heartbeat      time.Duration
+	idleTimeout    time.Duration
+	codec          notify.Codec
+}
+
+// New returns a gateway for notifier
+func New(notifier *notify.Notifier, options ...Option) *Handler {
+	handler := &Handler{
+		notifier:  notifier,
+		buffer:    DefaultBuffer,
+		heartbeat: 30 * time.Second,
+		codec:     notify.JSONCodec{},
+	}
+	for _, option := range options {
+		option(handler)
+	}
+	return handler
+}
+
+// A notification as sent to clients
+type message struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	events, err := handler.events(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if handler.auth != nil {
+		if err := handler.auth(r, events); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if isWebSocketUpgrade(r) {
+		handler.serveWebSocket(w, r, events)
+		return
+	}
+	handler.serveSSE(w, r, events)
+}
+
+// The events requested by the client, each of which must be allowed
+func (handler *Handler) events(r *http.Request) ([]string, error) {
+	var events []string
+	for _, value := range r.URL.Query()["event"] {
+		for _, event := range strings.Split(value, ",") {
+			if event = strings.TrimSpace(event); event != "" {
+				events = append(events, event)
+			}
+		}
+	}
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	for _, event := range events {
+		if !handler.allowed(event) {
+			return nil, ErrEventForbidden
+		}
+	}
+	return events, nil
+}
+
+func (handler *Handler) allowed(event string) bool {
+	for _, pattern := range handler.allow {
+		if matched, _ := path.Match(pattern, event); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// The observers and queue backing a single client connection
+type subscription struct {
+	observers map[string]*notify.Subscription
+	queue     chan message
+	overflow  chan struct{} // closed when the queue overflows with DisconnectSlow
+	dropped   atomic.Int64
+	wg        sync.WaitGroup
+	once      sync.Once
+}
+
+// Observe events for a connection. If one of them can't be observed (see
+// notify.Subscription.Err) the others are stopped and the error returned
+func (handler *Handler) subscribe(events []string) (*subscription, error) {
+	sub := &subscription{
+		observers: make(map[string]*notify.Subscription),
+		queue:     make(chan message, handler.buffer),
+		overflow:  make(chan struct{}),
+	}
+	for _, event := range events {
+		if _, ok := sub.observers[event]; ok {
+			continue
+		}
+		outputChan := make(chan interface{})
+		observer := handler.notifier.Start(event, outputChan, notify.WithChannelOwnership(), notify.WithComponent("httpgw"))
+		if err := observer.Err(); err != nil {
+			sub.close()
+			return nil, err
+		}
+		sub.observers[event] = observer
+
+		sub.wg.Add(1)
+		go sub.pump(event, outputChan, handler.disconnectSlow)
+	}
+	return sub, nil
+}
+
+// Move notifications from an observer into the connection's queue without
+// ever blocking the poster on a slow client
+func (sub *subscription) pump(event string, outputChan chan interface{}, disconnectSlow bool) {
+	defer sub.wg.Done()
+
+	for data := range outputChan {
+		select {
+		case sub.queue <- message{Event: event, Data: data}:
+		default:
+			sub.dropped.Add(1)
+			if disconnectSlow {
+				sub.once.Do(func() { close(sub.overflow) })
+			}
+		}
+	}
+}
+
+// Fires once a connection has been idle for the handler's idle timeout, as
+// told by the notifier's clock
+type idleTimer struct {
+	clock   notify.Clock
+	timer   notify.Timer
+	timeout time.Duration
+}
+
+func (handler *Handler) newIdleTimer() *idleTimer {
+	idle := &idleTimer{clock: handler.notifier.Clock(), timeout: handler.idleTimeout}
+	if idle.timeout > 0 {
+		idle.timer = idle.clock.NewTimer(idle.timeout)
+	}
+	return idle
+}
+
+// The channel the timer fires on, nil if idle connections are kept open
+func (idle *idleTimer) C() <-chan time.Time {
+	if idle.timer == nil {
+		return nil
+	}
+	return idle.timer.C()
+}
+
+// Restart the timer after a notification was sent
+func (idle *idleTimer) reset() {
+	if idle.timer != nil {
+		idle.timer.Stop()
+		idle.timer = idle.clock.NewTimer(idle.timeout)
+	}
+}
+
+func (idle *idleTimer) stop() {
+	if idle.timer != nil {
+		idle.timer.Stop()
+	}
+}
+
+func (sub *subscription) close() {
+	for _, observer := range sub.observers {
+		observer.Unsubscribe()
+	}
+	sub.wg.Wait()
+}
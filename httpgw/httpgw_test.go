@@ -0,0 +1,133 @@
+//go:build !notifyminimal
+
+package httpgw_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/httpgw"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+func TestSSERelays(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	server := httptest.NewServer(httpgw.New(notifier, httpgw.Allow("orders")))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?event=orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	// The headers are only sent once the client is observing
+	notifier.Post("orders", "order 1")
+
+	lines := bufio.NewScanner(resp.Body)
+	for _, want := range []string{"event: orders", `data: {"event":"orders","data":"order 1"}`} {
+		if !lines.Scan() {
+			t.Fatalf("stream ended before %q: %v", want, lines.Err())
+		}
+		if got := lines.Text(); got != want {
+			t.Fatalf("read %q, want %q", got, want)
+		}
+	}
+}
+
+func TestServeUnobservable(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		header http.Header
+	}{
+		{name: "sse"},
+		{name: "websocket", header: http.Header{
+			"Connection":            {"Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-WebSocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+			"Sec-WebSocket-Version": {"13"},
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			notifier := notify.NewNotifier(notify.WithMaxEvents(1))
+			defer notifier.Close()
+			server := httptest.NewServer(httpgw.New(notifier, httpgw.Allow("*")))
+			defer server.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"?event=orders,payments", nil)
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusServiceUnavailable {
+				t.Fatalf("status %d, want 503", resp.StatusCode)
+			}
+			for _, snapshot := range notifier.Snapshot() {
+				if len(snapshot.Subscribers) > 0 {
+					t.Fatalf("%s still observed after the request was refused", snapshot.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestSSEHeartbeatAndIdleTimeout(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock))
+	defer notifier.Close()
+	server := httptest.NewServer(httpgw.New(notifier, httpgw.Allow("orders"),
+		httpgw.WithHeartbeat(10*time.Second), httpgw.WithIdleTimeout(time.Minute)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?event=orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	// Wait for the heartbeat ticker and the idle timer
+	for clock.Timers() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(10 * time.Second)
+	select {
+	case line := <-lines:
+		if line != ": ping" {
+			t.Fatalf("read %q, want a heartbeat", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no heartbeat once the interval passed")
+	}
+
+	clock.Advance(time.Minute)
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("idle connection still open after the idle timeout")
+		}
+	}
+}
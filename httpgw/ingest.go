@@ -0,0 +1,167 @@
+//go:build !notifyminimal
+
+package httpgw
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// DefaultMaxBodySize is the largest request body an Ingest accepts unless
+// configured otherwise
+const DefaultMaxBodySize = 1 << 20
+
+// IngestOption configures an Ingest
+type IngestOption func(*Ingest)
+
+// IngestAllow lets clients post to events matching any of the patterns (see
+// path.Match). Nothing is allowed by default
+func IngestAllow(patterns ...string) IngestOption {
+	return func(ingest *Ingest) {
+		ingest.allow = append(ingest.allow, patterns...)
+	}
+}
+
+// IngestAuth rejects requests for which auth returns an error with 401
+// Unauthorized. It is also given the event being posted to
+func IngestAuth(auth func(r *http.Request, event string) error) IngestOption {
+	return func(ingest *Ingest) {
+		ingest.auth = auth
+	}
+}
+
+// MaxBodySize rejects request bodies over size bytes with 413 Request Entity
+// Too Large
+func MaxBodySize(size int64) IngestOption {
+	return func(ingest *Ingest) {
+		ingest.maxBodySize = size
+	}
+}
+
+// IngestCodec sets the codec used to decode request bodies (notify.JSONCodec
+// by default)
+func IngestCodec(codec notify.Codec) IngestOption {
+	return func(ingest *Ingest) {
+		ingest.codec = codec
+	}
+}
+
+// IngestQuarantine posts bodies that fail to decode to the event as
+// notify.Quarantined notifications. An empty event uses
+// notify.QuarantineEvent
+func IngestQuarantine(event string) IngestOption {
+	return func(ingest *Ingest) {
+		ingest.quarantine = true
+		ingest.quarantineEvent = event
+	}
+}
+
+// Ingest is an http.Handler posting request bodies into a notifier, so
+// external systems and webhooks can inject events. The event is the last
+// element of the request path, or the {name} wildcard when routed with one:
+//
+//	http.Handle("POST /events/{name}", httpgw.NewIngest(notifier, httpgw.IngestAllow("webhook.*")))
+//
+//	// curl -d '{"id": 42}' http://localhost/events/webhook.github
+//
+// Posts are made with PostContext using the request's context, tagged with
+// the client's address as their source (see notify.WithSource). Accepted
+// posts are answered with 202 Accepted
+type Ingest struct {
+	notifier        *notify.Notifier
+	allow           []string
+	auth            func(r *http.Request, event string) error
+	maxBodySize     int64
+	codec           notify.Codec
+	quarantine      bool
+	quarantineEvent string
+}
+
+// NewIngest returns an ingestion endpoint for notifier
+func NewIngest(notifier *notify.Notifier, options ...IngestOption) *Ingest {
+	ingest := &Ingest{
+		notifier:    notifier,
+		maxBodySize: DefaultMaxBodySize,
+		codec:       notify.JSONCodec{},
+	}
+	for _, option := range options {
+		option(ingest)
+	}
+	return ingest
+}
+
+func (ingest *Ingest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	event := r.PathValue("name")
+	if event == "" {
+		event = path.Base(strings.TrimSuffix(r.URL.Path, "/"))
+	}
+	if event == "" || event == "." || event == "/" {
+		http.Error(w, ErrNoEvents.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ingest.allowed(event) {
+		http.Error(w, ErrEventForbidden.Error(), http.StatusForbidden)
+		return
+	}
+	if ingest.auth != nil {
+		if err := ingest.auth(r, event); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ingest.maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := ingest.codec.Unmarshal(body)
+	if err != nil {
+		if ingest.quarantine {
+			ingest.notifier.PostQuarantined(ingest.quarantineEvent, notify.Quarantined{
+				Event:   event,
+				Source:  r.RemoteAddr,
+				Payload: body,
+				Err:     err,
+			})
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := notify.WithSource(r.Context(), r.RemoteAddr)
+	switch err := ingest.notifier.PostContext(ctx, event, data); {
+	case err == nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errors.Is(err, notify.ErrEventNotFound), errors.Is(err, notify.ErrNoSubscribers):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, notify.ErrRateLimited), errors.Is(err, notify.ErrPendingFull):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func (ingest *Ingest) allowed(event string) bool {
+	for _, pattern := range ingest.allow {
+		if matched, _ := path.Match(pattern, event); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+//go:build !notifyminimal
+
+package httpgw
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func (handler *Handler) serveSSE(w http.ResponseWriter, r *http.Request, events []string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub, err := handler.subscribe(events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.close()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var heartbeat <-chan time.Time
+	if handler.heartbeat > 0 {
+		ticker := handler.notifier.Clock().NewTicker(handler.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C()
+	}
+	idle := handler.newIdleTimer()
+	defer idle.stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.overflow:
+			return
+		case <-idle.C():
+			return
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case msg := <-sub.queue:
+			data, err := handler.codec.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", sseField(msg.Event), data); err != nil {
+				return
+			}
+			flusher.Flush()
+			idle.reset()
+		}
+	}
+}
+
+// Strip line breaks, which would end the field early
+func sseField(value string) string {
+	buf := []byte(value)
+	for i, c := range buf {
+		if c == '\n' || c == '\r' {
+			buf[i] = ' '
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"sort"
+	"time"
+)
+
+// EventSnapshot describes the state of a single event at the time Snapshot was
+// called
+type EventSnapshot struct {
+	Name        string
+	Created     time.Time // zero if the event only has pending notifications
+	Subscribers []SubscriberSnapshot
+	Pending     int // notifications buffered until the first observer starts
+}
+
+// SubscriberSnapshot describes an output channel observing an event
+type SubscriberSnapshot struct {
+	Queued   int // notifications waiting to be received on the channel
+	Capacity int
+	Labels   map[string]string
+}
+
+// Events returns the names of all started events in sorted order
+func (notifier *Notifier) Events() []string {
+	return notifier.eventNames()
+}
+
+func (notifier *Notifier) eventNames() []string {
+	var events []string
+	for i := range notifier.shards {
+		shard := &notifier.shards[i]
+		shard.RLock()
+		for event := range shard.events {
+			events = append(events, event)
+		}
+		shard.RUnlock()
+	}
+	sort.Strings(events)
+
+	return events
+}
+
+// SubscriberCount returns the number of output channels observing event
+func (notifier *Notifier) SubscriberCount(event string) int {
+	entry, ok := notifier.lookup(notifier.canonical(event))
+	if !ok {
+		return 0
+	}
+	return len(entry.observers())
+}
+
+// Snapshot describes every started event, its observers, and any pending
+// notifications, sorted by event name
+func (notifier *Notifier) Snapshot() []EventSnapshot {
+	snapshots := make(map[string]*EventSnapshot)
+	for i := range notifier.shards {
+		shard := &notifier.shards[i]
+		shard.RLock()
+		for event, entry := range shard.events {
+			subs := entry.observers()
+			snapshot := &EventSnapshot{
+				Name:        event,
+				Created:     entry.created,
+				Subscribers: make([]SubscriberSnapshot, 0, len(subs)),
+			}
+			for _, sub := range subs {
+				snapshot.Subscribers = append(snapshot.Subscribers, SubscriberSnapshot{
+					Queued:   len(sub.outputChan),
+					Capacity: cap(sub.outputChan),
+					Labels:   sub.labelsCopy(),
+				})
+			}
+			snapshots[event] = snapshot
+		}
+		shard.RUnlock()
+	}
+
+	notifier.pendingLock.Lock()
+	defer notifier.pendingLock.Unlock()
+
+	for event, pending := range notifier.pending {
+		snapshot, ok := snapshots[event]
+		if !ok {
+			snapshot = &EventSnapshot{Name: event}
+			snapshots[event] = snapshot
+		}
+		snapshot.Pending = len(pending)
+	}
+
+	result := make([]EventSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		result = append(result, *snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
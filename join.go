@@ -0,0 +1,43 @@
+package notify
+
+import "context"
+
+// JoinAll waits until every listed topic has had a notification, returning the
+// first one of each keyed by topic, ie: to hold a component's startup until
+// all of its dependencies have announced they're ready. Sticky events count
+// straight away with their retained notification (see WithSticky). If ctx is
+// done first it returns what has arrived so far along with ctx's error
+func (notifier *Notifier) JoinAll(ctx context.Context, topics ...string) (map[string]interface{}, error) {
+	outputChan := make(chan interface{}, len(topics))
+	subscriptions := Subscriptions(notifier.StartMulti(topics, outputChan))
+	defer subscriptions.Unsubscribe()
+
+	for _, subscription := range subscriptions {
+		if err := subscription.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Topics are told apart by the event they resolve to, as aliases of the
+	// same event are joined by the one notification
+	waiting := make(map[string][]string, len(topics))
+	for _, topic := range topics {
+		event := notifier.resolve(topic)
+		waiting[event] = append(waiting[event], topic)
+	}
+
+	joined := make(map[string]interface{}, len(topics))
+	for len(waiting) > 0 {
+		select {
+		case value := <-outputChan:
+			envelope, _ := value.(Envelope)
+			for _, topic := range waiting[envelope.Event] {
+				joined[topic] = envelope.Data
+			}
+			delete(waiting, envelope.Event)
+		case <-ctx.Done():
+			return joined, ctx.Err()
+		}
+	}
+	return joined, nil
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNoJournal = errors.New("No journal configured")
+
+// JournalEntry is a notification as written to a Journal
+type JournalEntry struct {
+	Event   string    `json:"event"`
+	Posted  time.Time `json:"posted"`
+	Payload []byte    `json:"payload"` // the notification encoded by the journal codec
+}
+
+// Journal durably stores notifications posted to designated events
+type Journal interface {
+	// Append must not return until entry is safely stored
+	Append(entry JournalEntry) error
+	// Replay calls fn, in order, with every entry for event posted at or
+	// after from. Replay stops at the first error returned by fn
+	Replay(event string, from time.Time, fn func(entry JournalEntry) error) error
+}
+
+// WithJournal writes every notification posted to the listed events to
+// journal, encoded with codec, before it is delivered. If the write fails the
+// post fails and nothing is delivered. Notifications sent with
+// PostGenerateData are not journaled
+func WithJournal(journal Journal, codec Codec, events ...string) Option {
+	return func(notifier *Notifier) {
+		notifier.journal = journal
+		notifier.journalCodec = codec
+		for _, event := range events {
+			notifier.journaled[event] = true
+		}
+	}
+}
+
+// Write a notification to the journal if its event is journaled
+func (notifier *Notifier) writeJournal(event string, data interface{}) error {
+	if !notifier.journaled[event] {
+		return nil
+	}
+	payload, err := notifier.journalCodec.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return notifier.journal.Append(JournalEntry{Event: event, Posted: notifier.clock.Now(), Payload: payload})
+}
+
+// ReplayJournal sends every journaled notification for event posted at or
+// after from to outputChan, oldest first. It returns once all of them have
+// been received
+func (notifier *Notifier) ReplayJournal(event string, from time.Time, outputChan chan interface{}) error {
+	if notifier.journal == nil {
+		return ErrNoJournal
+	}
+	return notifier.journal.Replay(event, from, func(entry JournalEntry) error {
+		data, err := notifier.journalCodec.Unmarshal(entry.Payload)
+		if err != nil {
+			return err
+		}
+		outputChan <- data
+		return nil
+	})
+}
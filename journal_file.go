@@ -0,0 +1,84 @@
+//go:build !notifyminimal
+
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileJournal is a Journal appending one JSON document per entry to a file,
+// syncing it to disk after every write
+type FileJournal struct {
+	path string
+	file *os.File
+	sync.Mutex
+}
+
+// OpenFileJournal opens the journal at path, creating it if needed
+func OpenFileJournal(path string) (*FileJournal, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileJournal{path: path, file: file}, nil
+}
+
+func (journal *FileJournal) Append(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	journal.Lock()
+	defer journal.Unlock()
+
+	if _, err := journal.file.Write(line); err != nil {
+		return err
+	}
+	return journal.file.Sync()
+}
+
+func (journal *FileJournal) Replay(event string, from time.Time, fn func(entry JournalEntry) error) error {
+	file, err := os.Open(journal.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A partial last line is a write interrupted by a crash
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry JournalEntry
+		if err := json.Unmarshal(bytes.TrimSpace(line), &entry); err != nil {
+			return err
+		}
+		if entry.Event != event || entry.Posted.Before(from) {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+func (journal *FileJournal) Close() error {
+	journal.Lock()
+	defer journal.Unlock()
+
+	return journal.file.Close()
+}
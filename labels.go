@@ -0,0 +1,56 @@
+package notify
+
+// WithLabels attaches labels to the observer, ie: the component that started
+// it, so it can later be found by StopWhere
+func WithLabels(labels map[string]string) SubscribeOption {
+	return func(sub *subscriber) {
+		if sub.labels == nil {
+			sub.labels = make(map[string]string, len(labels))
+		}
+		for key, value := range labels {
+			sub.labels[key] = value
+		}
+	}
+}
+
+// WithLabel attaches a single label to the observer
+func WithLabel(key string, value string) SubscribeOption {
+	return WithLabels(map[string]string{key: value})
+}
+
+// SubscriptionInfo describes an observer to StopWhere
+type SubscriptionInfo struct {
+	Event  string
+	Labels map[string]string
+}
+
+// StopWhere stops every observer for which match returns true and returns how
+// many were stopped. Output channels are closed as with Stop
+func (notifier *Notifier) StopWhere(match func(info SubscriptionInfo) bool) int {
+	notifier.Lock()
+	defer notifier.Unlock()
+
+	stopped := 0
+	for _, event := range notifier.eventNames() {
+		notifier.stop(event, func(sub *subscriber) bool {
+			if match(SubscriptionInfo{Event: event, Labels: sub.labelsCopy()}) {
+				stopped++
+				return true
+			}
+			return false
+		})
+	}
+
+	return stopped
+}
+
+func (sub *subscriber) labelsCopy() map[string]string {
+	if sub.labels == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(sub.labels))
+	for key, value := range sub.labels {
+		labels[key] = value
+	}
+	return labels
+}
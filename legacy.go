@@ -0,0 +1,61 @@
+package notify
+
+import "time"
+
+// LegacyNotifier is the original channel based API, for code that declared it
+// as an interface before Start returned a Subscription
+type LegacyNotifier interface {
+	Start(event string, outputChan chan interface{})
+	Stop(event string, outputChan chan interface{}) error
+	StopAll(event string) error
+	Post(event string, data interface{}) error
+	PostTimeout(event string, data interface{}, timeout time.Duration) error
+}
+
+type legacyNotifier struct {
+	notifier *Notifier
+}
+
+// Legacy adapts the notifier to LegacyNotifier so code written against the
+// original API keeps working while it is migrated. Observers started through
+// it can be moved over one at a time with SubscriptionFor
+func (notifier *Notifier) Legacy() LegacyNotifier {
+	return legacyNotifier{notifier: notifier}
+}
+
+func (legacy legacyNotifier) Start(event string, outputChan chan interface{}) {
+	legacy.notifier.Start(event, outputChan)
+}
+
+func (legacy legacyNotifier) Stop(event string, outputChan chan interface{}) error {
+	return legacy.notifier.Stop(event, outputChan)
+}
+
+func (legacy legacyNotifier) StopAll(event string) error {
+	return legacy.notifier.StopAll(event)
+}
+
+func (legacy legacyNotifier) Post(event string, data interface{}) error {
+	return legacy.notifier.Post(event, data)
+}
+
+func (legacy legacyNotifier) PostTimeout(event string, data interface{}, timeout time.Duration) error {
+	return legacy.notifier.PostTimeout(event, data, timeout)
+}
+
+// SubscriptionFor returns the Subscription of the first observer of event on
+// outputChan, for migrating code that started it without keeping the
+// Subscription
+func (notifier *Notifier) SubscriptionFor(event string, outputChan chan interface{}) (*Subscription, bool) {
+	event = notifier.canonical(event)
+	entry, ok := notifier.lookup(event)
+	if !ok {
+		return nil, false
+	}
+	for _, sub := range entry.observers() {
+		if sub.outputChan == outputChan {
+			return &Subscription{notifier: notifier, event: event, sub: sub}, true
+		}
+	}
+	return nil, false
+}
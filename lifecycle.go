@@ -0,0 +1,95 @@
+package notify
+
+// Callbacks for the observers of an event coming and going
+type lifecycleHooks struct {
+	first []func(event string)
+	last  []func(event string)
+}
+
+// OnFirstSubscriber calls fn whenever event goes from having no observers to
+// having one, and right away if it is already observed, so producers can
+// start expensive work only while someone is listening. Like
+// OnLastUnsubscribe, callbacks run in order on a separate goroutine once the
+// observer has started
+func (notifier *Notifier) OnFirstSubscriber(event string, fn func(event string)) {
+	shard, event := notifier.lockEvent(event)
+	defer notifier.unlockEvent(shard)
+
+	notifier.lifecycleLock.Lock()
+	defer notifier.lifecycleLock.Unlock()
+
+	hooks := notifier.hooksFor(event)
+	hooks.first = append(hooks.first, fn)
+	if entry, ok := shard.events[event]; ok && len(entry.observers()) > 0 {
+		notifier.queueLifecycle(event, []func(event string){fn})
+	}
+}
+
+// OnLastUnsubscribe calls fn whenever the last observer of event is stopped
+func (notifier *Notifier) OnLastUnsubscribe(event string, fn func(event string)) {
+	shard, event := notifier.lockEvent(event)
+	defer notifier.unlockEvent(shard)
+
+	notifier.lifecycleLock.Lock()
+	defer notifier.lifecycleLock.Unlock()
+
+	hooks := notifier.hooksFor(event)
+	hooks.last = append(hooks.last, fn)
+}
+
+// Must be called with lifecycleLock held
+func (notifier *Notifier) hooksFor(event string) *lifecycleHooks {
+	hooks, ok := notifier.lifecycle[event]
+	if !ok {
+		hooks = &lifecycleHooks{}
+		notifier.lifecycle[event] = hooks
+	}
+	return hooks
+}
+
+// Queue the callbacks for event gaining its first observer (first) or losing
+// its last one. Must be called with the event's shard or the notifier locked
+// so callbacks are queued in the order the changes happen
+func (notifier *Notifier) observed(event string, first bool) {
+	notifier.lifecycleLock.Lock()
+	defer notifier.lifecycleLock.Unlock()
+
+	hooks, ok := notifier.lifecycle[event]
+	if !ok {
+		return
+	}
+	if first {
+		notifier.queueLifecycle(event, hooks.first)
+	} else {
+		notifier.queueLifecycle(event, hooks.last)
+	}
+}
+
+// Must be called with lifecycleLock held
+func (notifier *Notifier) queueLifecycle(event string, fns []func(event string)) {
+	for _, fn := range fns {
+		fn := fn
+		notifier.lifecycleQueue = append(notifier.lifecycleQueue, func() { fn(event) })
+	}
+	if len(notifier.lifecycleQueue) > 0 && !notifier.lifecycleRunning {
+		notifier.lifecycleRunning = true
+		go notifier.runLifecycle()
+	}
+}
+
+// Run queued callbacks until there are none left
+func (notifier *Notifier) runLifecycle() {
+	for {
+		notifier.lifecycleLock.Lock()
+		if len(notifier.lifecycleQueue) == 0 {
+			notifier.lifecycleRunning = false
+			notifier.lifecycleLock.Unlock()
+			return
+		}
+		fn := notifier.lifecycleQueue[0]
+		notifier.lifecycleQueue = notifier.lifecycleQueue[1:]
+		notifier.lifecycleLock.Unlock()
+
+		fn()
+	}
+}
@@ -0,0 +1,47 @@
+package notify
+
+import "runtime"
+
+// TiedTo stops the observer once owner is garbage collected, so components
+// created and dropped dynamically don't leak their observers when nobody
+// calls Unsubscribe. The observer must not keep owner reachable, ie: a
+// Handler that is a method value of owner or an output channel only owner
+// reads from keeps it alive forever. Prefer explicit teardown (see WithDone
+// and Subscription.Close) where the component's lifetime is known
+func TiedTo[T any](owner *T) SubscribeOption {
+	return func(sub *subscriber) {
+		if owner == nil {
+			return
+		}
+		sub.binds = append(sub.binds, func(subscription *Subscription) {
+			runtime.AddCleanup(owner, func(subscription *Subscription) {
+				subscription.Unsubscribe()
+			}, subscription)
+		})
+	}
+}
+
+// WithDone stops the observer once done is closed, ie: a component's shutdown
+// channel or ctx.Done()
+func WithDone(done <-chan struct{}) SubscribeOption {
+	return func(sub *subscriber) {
+		sub.binds = append(sub.binds, func(subscription *Subscription) {
+			stopped := subscription.sub.done
+			go func() {
+				select {
+				case <-done:
+					subscription.Unsubscribe()
+				case <-stopped:
+				}
+			}()
+		})
+	}
+}
+
+// Close stops the observer like Unsubscribe, so subscriptions can be torn
+// down along with a component's other io.Closers. Closing an observer that
+// was already stopped isn't an error
+func (subscription *Subscription) Close() error {
+	subscription.Unsubscribe()
+	return nil
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a Handler, ie: to recover from panics, measure or retry it
+type Middleware func(next Handler) Handler
+
+// SubscribeOption configures a single observer
+type SubscribeOption func(*subscriber)
+
+// WithMiddleware wraps the observer's Handler in middleware. The first
+// middleware given is the outermost one
+func WithMiddleware(middleware ...Middleware) SubscribeOption {
+	return func(sub *subscriber) {
+		sub.middleware = append(sub.middleware, middleware...)
+	}
+}
+
+// Apply an observer's middleware to its handler
+func chain(handler Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// PanicError is returned by Recover when a Handler panics
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("handler panic: %v", err.Value)
+}
+
+// Recover turns a panicking Handler into one returning a *PanicError
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, data interface{}) (err error) {
+			defer func() {
+				if value := recover(); value != nil {
+					err = &PanicError{Value: value, Stack: debug.Stack()}
+				}
+			}()
+			return next(ctx, data)
+		}
+	}
+}
+
+// Measure reports how long every call to the Handler took and its result
+func Measure(report func(event string, elapsed time.Duration, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, data interface{}) error {
+			clock := ClockFromContext(ctx)
+			start := clock.Now()
+			err := next(ctx, data)
+
+			envelope, _ := EnvelopeFromContext(ctx)
+			report(envelope.Event, clock.Now().Sub(start), err)
+			return err
+		}
+	}
+}
+
+// Retry calls the Handler up to attempts times until it succeeds, waiting
+// backoff (doubled after every failure) in between. It gives up early if the
+// delivery context is done. The Handler is always called at least once
+func Retry(attempts int, backoff time.Duration) Middleware {
+	attempts = max(attempts, 1)
+	return func(next Handler) Handler {
+		return func(ctx context.Context, data interface{}) error {
+			var err error
+			wait := backoff
+			for attempt := 0; attempt < attempts; attempt++ {
+				if err = next(ctx, data); err == nil {
+					return nil
+				}
+				if attempt == attempts-1 {
+					break
+				}
+
+				timer := ClockFromContext(ctx).NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				case <-timer.C():
+				}
+				wait *= 2
+			}
+			return err
+		}
+	}
+}
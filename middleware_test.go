@@ -0,0 +1,97 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+var errDeliveryFailed = errors.New("delivery failed")
+
+func TestRetryAttempts(t *testing.T) {
+	for _, test := range []struct {
+		attempts int
+		calls    int32
+	}{
+		{attempts: -1, calls: 1},
+		{attempts: 0, calls: 1},
+		{attempts: 1, calls: 1},
+		{attempts: 4, calls: 4},
+	} {
+		t.Run(strconv.Itoa(test.attempts), func(t *testing.T) {
+			clock := notifytest.NewFakeClock(time.Unix(0, 0))
+			errs := make(chan error, 1)
+			notifier := notify.NewNotifier(notify.WithClock(clock), notify.WithErrorHandler(func(event string, err error) {
+				errs <- err
+			}))
+			defer notifier.Close()
+
+			var calls atomic.Int32
+			notifier.StartFunc("orders", func(ctx context.Context, data interface{}) error {
+				calls.Add(1)
+				return errDeliveryFailed
+			}, notify.WithMiddleware(notify.Retry(test.attempts, time.Second)))
+			notifier.Post("orders", 1)
+
+			deadline := time.Now().Add(time.Second)
+			for {
+				select {
+				case err := <-errs:
+					if err != errDeliveryFailed {
+						t.Fatalf("reported %v, want the handler's error", err)
+					}
+					if got := calls.Load(); got != test.calls {
+						t.Fatalf("handler called %d times, want %d", got, test.calls)
+					}
+					return
+				default:
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("no error reported after %d calls", calls.Load())
+				}
+				// Let every backoff expire, whatever its length
+				if clock.Timers() > 0 {
+					clock.Advance(time.Minute)
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnSuccess(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock))
+	defer notifier.Close()
+
+	var calls atomic.Int32
+	done := make(chan struct{})
+	notifier.StartFunc("orders", func(ctx context.Context, data interface{}) error {
+		if calls.Add(1) < 2 {
+			return errDeliveryFailed
+		}
+		close(done)
+		return nil
+	}, notify.WithMiddleware(notify.Retry(5, time.Second)))
+	notifier.Post("orders", 1)
+
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler not retried once the backoff expired")
+	}
+	notifier.Close()
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("handler called %d times, want 2", got)
+	}
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"math/rand/v2"
+	"path"
+)
+
+// DefaultMirrorQueue is the number of notifications waiting to be mirrored
+// when a MirrorConfig doesn't set one
+const DefaultMirrorQueue = 256
+
+// MirrorConfig picks the notifications WithMirror copies to a recorder
+type MirrorConfig struct {
+	// Patterns (see path.Match) of the events mirrored, every event if empty
+	Events []string
+	// Share of their notifications mirrored, picked at random. Zero mirrors
+	// every one
+	Fraction float64
+	// Notifications waiting to be posted to the recorder. The ones that don't
+	// fit are dropped rather than holding up the post
+	Queue int
+}
+
+// WithMirror posts a sample of the notifications posted to the notifier,
+// picked by config, to recorder as well, so canary code or verification jobs
+// can observe live traffic on recorder without being added to the real
+// subscriber lists. Notifications are mirrored whether or not their events
+// have observers, and handed to recorder as posted, from a goroutine of their
+// own; posts recorder fails or rejects for lack of observers are ignored.
+// recorder is typically a NewBoundedNotifier kept in memory so live traffic
+// can't grow it without bound. Mirroring stops once the notifier is closed:
+// only a notification already being posted to recorder gets there, the ones
+// still queued are dropped and counted as such, and recorder is left open.
+// See Stats for how much was mirrored and dropped
+func WithMirror(recorder *Notifier, config MirrorConfig) Option {
+	return func(notifier *Notifier) {
+		if config.Queue <= 0 {
+			config.Queue = DefaultMirrorQueue
+		}
+		notifier.mirror = recorder
+		notifier.mirrorConfig = config
+	}
+}
+
+// A notification waiting to be mirrored
+type mirrored struct {
+	event string
+	data  interface{}
+	size  int // counted against the memory budget, see WithMaxMemory
+}
+
+// Whether the notifications of event are mirrored at all
+func (notifier *Notifier) mirrors(event string) bool {
+	if notifier.mirror == nil {
+		return false
+	}
+	if len(notifier.mirrorConfig.Events) == 0 {
+		return true
+	}
+	for _, pattern := range notifier.mirrorConfig.Events {
+		if matched, _ := path.Match(pattern, event); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Queue a notification posted to event for the recorder if it is picked
+func (notifier *Notifier) mirrorPost(event string, data interface{}) {
+	if !notifier.mirrors(event) {
+		return
+	}
+	if fraction := notifier.mirrorConfig.Fraction; fraction > 0 && rand.Float64() >= fraction {
+		return
+	}
+
+	m := mirrored{event: event, data: data}
+	if notifier.memory != nil {
+		m.size = notifier.memory.size(data)
+	}
+
+	notifier.mirrorLock.Lock()
+	defer notifier.mirrorLock.Unlock()
+
+	if notifier.mirrorClosed {
+		return
+	}
+	if len(notifier.mirrorQueue) >= notifier.mirrorConfig.Queue || !notifier.memory.fits(m.size) {
+		notifier.stats.mirrorDropped.Add(1)
+		return
+	}
+	notifier.memory.charge(m.size)
+	notifier.mirrorQueue = append(notifier.mirrorQueue, m)
+	if !notifier.mirrorRunning {
+		notifier.mirrorRunning = true
+		go notifier.runMirror()
+	}
+}
+
+// Post the queued notifications to the recorder, until the queue is empty,
+// as it is once the notifier is closed
+func (notifier *Notifier) runMirror() {
+	for {
+		notifier.mirrorLock.Lock()
+		if len(notifier.mirrorQueue) == 0 {
+			notifier.mirrorRunning = false
+			notifier.mirrorLock.Unlock()
+			return
+		}
+		m := notifier.mirrorQueue[0]
+		notifier.mirrorQueue = notifier.mirrorQueue[1:]
+		notifier.mirrorLock.Unlock()
+		notifier.memory.charge(-m.size)
+
+		notifier.mirror.Post(m.event, m.data)
+		notifier.stats.mirrored.Add(1)
+	}
+}
+
+// Stop mirroring once the notifier is closed, dropping the queued
+// notifications
+func (notifier *Notifier) stopMirror() {
+	notifier.mirrorLock.Lock()
+	defer notifier.mirrorLock.Unlock()
+
+	notifier.mirrorClosed = true
+	for _, m := range notifier.mirrorQueue {
+		notifier.memory.charge(-m.size)
+	}
+	notifier.stats.mirrorDropped.Add(uint64(len(notifier.mirrorQueue)))
+	notifier.mirrorQueue = nil
+}
@@ -0,0 +1,71 @@
+package notify
+
+import "sync/atomic"
+
+// StartMulti observes every listed event on outputChan, delivering Envelopes
+// (see WithEnvelopes) so a single consumer can tell which event each
+// notification was posted to. With WithChannelOwnership the channel is closed
+// once all of the events have stopped being observed
+func (notifier *Notifier) StartMulti(events []string, outputChan chan interface{}, options ...SubscribeOption) []*Subscription {
+	if notifier == nil || outputChan == nil {
+		err := ErrNilChannel
+		if notifier == nil {
+			err = ErrNilNotifier
+		}
+		subscriptions := make([]*Subscription, len(events))
+		for i, event := range events {
+			subscriptions[i] = failedSubscription(event, err)
+		}
+		return subscriptions
+	}
+	unique := make([]string, 0, len(events))
+	seen := make(map[string]bool, len(events))
+	for _, event := range events {
+		event = notifier.resolve(event)
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+
+	group := new(atomic.Int32)
+	group.Store(int32(len(unique)))
+
+	subscriptions := make([]*Subscription, 0, len(unique))
+	for _, event := range unique {
+		sub := &subscriber{outputChan: outputChan, envelopes: true, group: group}
+		for _, option := range options {
+			option(sub)
+		}
+
+		shard, event := notifier.lockEvent(event)
+		if err := notifier.admit(event, 1); err != nil {
+			notifier.unlockEvent(shard)
+			if sub.owned && group.Add(-1) == 0 {
+				close(outputChan)
+			}
+			subscriptions = append(subscriptions, failedSubscription(event, err))
+			continue
+		}
+		notifier.start(event, sub)
+		notifier.unlockEvent(shard)
+
+		subscriptions = append(subscriptions, &Subscription{notifier: notifier, event: event, sub: sub})
+	}
+	return subscriptions
+}
+
+// StopMulti stops observing every listed event on outputChan. All of them
+// are stopped even if some weren't started, in which case ErrEventNotFound
+// is returned
+//
+// Deprecated: use Unsubscribe on the Subscriptions returned by StartMulti
+func (notifier *Notifier) StopMulti(events []string, outputChan chan interface{}) error {
+	var err error
+	for _, event := range events {
+		if stopErr := notifier.Stop(event, outputChan); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}
+	return err
+}
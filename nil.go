@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrNilNotifier = errors.New("Notifier is nil")
+	ErrNilChannel  = errors.New("Output channel is nil")
+	ErrNilHandler  = errors.New("Handler is nil")
+	ErrNilPayload  = errors.New("Nil payload rejected")
+)
+
+// WithRejectNil makes posts of nil payloads, including nil pointers, to any
+// of the events fail with ErrNilPayload instead of delivering nil to their
+// observers. Without events, nil payloads are rejected for every event
+func WithRejectNil(events ...string) Option {
+	return func(notifier *Notifier) {
+		if len(events) == 0 {
+			notifier.rejectNilAll = true
+			return
+		}
+		if notifier.rejectNil == nil {
+			notifier.rejectNil = make(map[string]bool)
+		}
+		for _, event := range events {
+			notifier.rejectNil[event] = true
+		}
+	}
+}
+
+// Fail a post of data to event if it is nil and nil payloads are rejected
+func (notifier *Notifier) checkNil(event string, data interface{}) error {
+	if !notifier.rejectNilAll && !notifier.rejectNil[event] {
+		return nil
+	}
+	if isNil(data) {
+		return fmt.Errorf("%w: %q", ErrNilPayload, event)
+	}
+	return nil
+}
+
+func isNil(data interface{}) bool {
+	if data == nil {
+		return true
+	}
+	value := reflect.ValueOf(data)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+// A Subscription for an observer that couldn't be started, whose Unsubscribe
+// and Err return err
+func failedSubscription(event string, err error) *Subscription {
+	return &Subscription{event: event, err: err}
+}
@@ -0,0 +1,25 @@
+package notify
+
+// WithNormalizer canonicalizes every notification posted to event with
+// normalize (ie: trimming strings, converting units or upgrading old payload
+// structs) before it is journaled, sampled or delivered to any observer, so
+// consumers only ever see one form. Normalizers of the same event run in the
+// order given. An error fails the post with it and nothing is delivered.
+// Replayed notifications were journaled normalized and aren't normalized
+// again
+func WithNormalizer(event string, normalize func(data interface{}) (interface{}, error)) Option {
+	return func(notifier *Notifier) {
+		notifier.normalizers[event] = append(notifier.normalizers[event], normalize)
+	}
+}
+
+// Run the normalizers of event on data
+func (notifier *Notifier) normalize(event string, data interface{}) (interface{}, error) {
+	for _, normalize := range notifier.normalizers[event] {
+		var err error
+		if data, err = normalize(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
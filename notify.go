@@ -6,6 +6,15 @@
 // for components to have intimate knowledge of each other (only `import notify`
 // and the name of the event are shared).
 //
+// Building with the notifyminimal tag leaves out the parts of the package that
+// depend on encoding/json, encoding/gob and the file system: JSONCodec,
+// GobCodec, the file backed journal, sticky and schedule stores,
+// LoadPipelines and ExportState/ImportState, for embedded targets that only
+// need the notifier itself. The bridge, httpgw and debug packages, which
+// default to JSONCodec, are left out as a whole; upgrade still builds, though
+// the state it hands over comes from ExportState. Stats, middleware and
+// runtime/trace annotations stay in, having no such dependencies.
+//
 // Example:
 //     notifier := notify.NewNotifier()
 //     // producer of "my_event"
@@ -29,138 +38,1204 @@
 package notify
 
 import (
+	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	ErrEventNotFound = errors.New("Event not found")
 	ErrPostTimedOut  = errors.New("Post event timed out")
+	ErrNoSubscribers = errors.New("Event has no subscribers")
+	ErrPendingFull   = errors.New("Pending buffer full")
 )
 
+// NoSubscribersPolicy controls what Post does when an event has no observers
+type NoSubscribersPolicy int
+
+const (
+	// Return ErrEventNotFound for events that were never started (default)
+	NoSubscribersNotFound NoSubscribersPolicy = iota
+	// Silently drop the notification
+	NoSubscribersIgnore
+	// Return ErrNoSubscribers
+	NoSubscribersError
+	// Hold the notification until the first observer starts, up to the
+	// pending limit
+	NoSubscribersBuffer
+)
+
+// DefaultPendingLimit is the number of notifications buffered per event when
+// using NoSubscribersBuffer and no explicit limit was given
+const DefaultPendingLimit = 64
+
+// Option configures a Notifier
+type Option func(*Notifier)
+
+// WithNoSubscribers sets the policy used when posting to an event with no
+// observers
+func WithNoSubscribers(policy NoSubscribersPolicy) Option {
+	return func(notifier *Notifier) {
+		notifier.noSubscribers = policy
+	}
+}
+
+// WithPendingLimit caps the number of notifications buffered per event under
+// NoSubscribersBuffer
+func WithPendingLimit(limit int) Option {
+	return func(notifier *Notifier) {
+		notifier.pendingLimit = limit
+	}
+}
+
+// DefaultFanOutThreshold is the number of observers an event needs before
+// posts are delivered in parallel when WithParallelDelivery is used without a
+// threshold
+const DefaultFanOutThreshold = 32
+
+// WithParallelDelivery delivers posts to events with at least threshold
+// observers on up to workers goroutines, so Post and PostTimeout take about as
+// long as the slowest observer instead of the sum of all of them. A threshold
+// of zero uses DefaultFanOutThreshold. The order in which observers of a
+// single post receive it is unspecified
+func WithParallelDelivery(workers int, threshold int) Option {
+	return func(notifier *Notifier) {
+		if threshold <= 0 {
+			threshold = DefaultFanOutThreshold
+		}
+		notifier.fanOutWorkers = workers
+		notifier.fanOutThreshold = threshold
+	}
+}
+
+// WithDeadlineReserve makes PostContext give up on blocked observers, and
+// expire the contexts of callback observers, reserve before the deadline of
+// the context it was given rather than at the deadline itself, leaving the
+// caller reserve to finish the request that posted once Post returns. Posts
+// with contexts that have no deadline are unaffected
+func WithDeadlineReserve(reserve time.Duration) Option {
+	return func(notifier *Notifier) {
+		notifier.deadlineReserve = reserve
+	}
+}
+
+// WithPostHook calls hook with every notification posted, once it passes any
+// rate limit and before it is delivered, ie: to record posts in tests (see
+// notifytest). hook runs on the posting goroutine
+func WithPostHook(hook func(event string, data interface{})) Option {
+	return func(notifier *Notifier) {
+		notifier.postHook = hook
+	}
+}
+
 // returns the current version
 func Version() string {
 	return "0.3"
 }
 
+// Observers and bookkeeping for a single event
+type eventEntry struct {
+	// Replaced, never modified, while the event's shard is locked so posts
+	// can deliver to a snapshot without holding any lock
+	subscribers atomic.Pointer[[]*subscriber]
+	created     time.Time
+
+	// seq, history and active change while posts are delivered concurrently
+	sync.Mutex
+	seq     uint64
+	history []record
+	active  time.Time // last post, or when the last observer went away
+
+	global      *atomic.Uint64 // notifier wide sequence, nil unless enabled
+	deliverLock sync.Mutex     // serializes posts with ordered delivery
+	clock       Clock
+
+	memory   *memoryBudget // nil unless WithMaxMemory
+	retained int           // size of the history
+}
+
+// Number of independently locked partitions of the event map
+const eventShards = 32
+
+// A partition of the notifier's events
+type eventShard struct {
+	events map[string]*eventEntry
+	sync.RWMutex
+}
+
+// A single observer of an event
+type subscriber struct {
+	outputChan chan interface{}
+	watch      bool                    // deliver records instead of the posted data
+	envelopes  bool                    // deliver Envelopes instead of the posted data
+	handler    Handler                 // deliver invocations to a callback
+	invoke     func(value interface{}) // call handler in place of sending, see WithSyncDispatch
+	middleware []Middleware
+	labels     map[string]string
+
+	minSeverity Severity
+	paused      atomic.Bool
+	owned       bool          // close outputChan once stopped
+	group       *atomic.Int32 // observers sharing an owned outputChan, see StartMulti
+
+	concurrency int
+	ordered     bool
+
+	trace *subscriptionTrace // see WithSubscriptionTracing
+	errs  chan error         // see WithErrors
+	// Tie the observer's lifetime to something else once started, see
+	// TiedTo and WithDone
+	binds []func(subscription *Subscription)
+
+	// Keeps outputChan from being closed mid send. Senders hold it for
+	// reading and give up once done is closed
+	sendLock sync.RWMutex
+	done     chan struct{}
+	stopped  bool
+}
+
+// The current observers of the event
+func (entry *eventEntry) observers() []*subscriber {
+	if subs := entry.subscribers.Load(); subs != nil {
+		return *subs
+	}
+	return nil
+}
+
+// Replace the observers of the event. Must be called with the event's shard
+// locked
+func (entry *eventEntry) setObservers(subs []*subscriber) {
+	entry.subscribers.Store(&subs)
+}
+
+// Send value to the observer, blocking until it is received. Returns false if
+// the observer was stopped first
+func (sub *subscriber) send(value interface{}) bool {
+	if sub.invoke != nil {
+		return sub.call(value)
+	}
+	sub.sendLock.RLock()
+	defer sub.sendLock.RUnlock()
+
+	if sub.stopped {
+		return false
+	}
+	select {
+	case sub.outputChan <- value:
+		return true
+	case <-sub.done:
+		return false
+	}
+}
+
+// Send value to the observer if it is ready to receive it. Returns false if
+// it would block
+func (sub *subscriber) trySend(value interface{}) bool {
+	if sub.invoke != nil {
+		sub.call(value)
+		return true
+	}
+	sub.sendLock.RLock()
+	defer sub.sendLock.RUnlock()
+
+	if sub.stopped {
+		return true
+	}
+	select {
+	case sub.outputChan <- value:
+	case <-sub.done:
+	default:
+		return false
+	}
+	return true
+}
+
+// Send value to the observer, giving up once ctx is done. Returns false if it
+// gave up
+func (sub *subscriber) sendContext(ctx context.Context, value interface{}) bool {
+	if sub.invoke != nil {
+		sub.call(value)
+		return true
+	}
+	sub.sendLock.RLock()
+	defer sub.sendLock.RUnlock()
+
+	if sub.stopped {
+		return true
+	}
+	select {
+	case sub.outputChan <- value:
+	case <-sub.done:
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+// Hand value straight to a synchronous observer. Returns false if the
+// observer was stopped first. The send lock isn't held while the handler runs
+// so it may stop observers, including itself
+func (sub *subscriber) call(value interface{}) bool {
+	sub.sendLock.RLock()
+	stopped := sub.stopped
+	sub.sendLock.RUnlock()
+
+	if stopped {
+		return false
+	}
+	sub.invoke(value)
+	return true
+}
+
+// Stop sending to the observer, waiting for in-flight sends to give up. Must
+// be called with the event's shard locked
+func (sub *subscriber) halt() {
+	if sub.stopped {
+		return
+	}
+
+	close(sub.done)
+	sub.sendLock.Lock()
+	defer sub.sendLock.Unlock()
+
+	sub.stopped = true
+	if sub.errs != nil {
+		close(sub.errs)
+	}
+	if sub.trace != nil {
+		sub.trace.stopped()
+	}
+}
+
+// Stop the observers in stopped, then close every owned output channel of
+// theirs that none of remaining still delivers to. The same channel may
+// observe an event more than once
+func closeObservers(stopped []*subscriber, remaining []*subscriber) {
+	closed := make(map[chan interface{}]bool, len(remaining))
+	for _, sub := range remaining {
+		closed[sub.outputChan] = true
+	}
+	for _, sub := range stopped {
+		sub.halt()
+	}
+	for _, sub := range stopped {
+		if !sub.owned || closed[sub.outputChan] {
+			continue
+		}
+		closed[sub.outputChan] = true
+		if sub.group == nil || sub.group.Add(-1) == 0 {
+			close(sub.outputChan)
+		}
+	}
+}
+
+// A posted notification
+type record struct {
+	seq    uint64
+	global uint64
+	posted time.Time
+	data   interface{}
+	size   int // counted against the memory budget, see WithMaxMemory
+}
+
+// State shared by every delivery of a single post
+type postContext struct {
+	ctx      context.Context // parent of the contexts handed to handlers
+	deadline time.Time
+	severity Severity
+	source   string
+	replay   bool        // posted by Replay, already journaled
+	waiter   *postWaiter // set by PostWait
+	admitted bool        // already past the close policy and rate limits
+}
+
+var backgroundPost = postContext{ctx: context.Background(), severity: SeverityInfo}
+
+// Assign the next sequence number to data, retaining it in the event's
+// history if the notifier keeps any
+func (entry *eventEntry) record(data interface{}, historySize int) record {
+	entry.Lock()
+	defer entry.Unlock()
+
+	return entry.next(data, historySize)
+}
+
+// Record data like record and take the snapshot of observers it is delivered
+// to. Both happen under the entry's lock so an observer that reads the history
+// after starting (see Watch) receives every later notification
+func (entry *eventEntry) publish(data interface{}, historySize int) (record, []*subscriber) {
+	entry.Lock()
+	defer entry.Unlock()
+
+	return entry.next(data, historySize), entry.observers()
+}
+
+// Must be called with the entry locked
+func (entry *eventEntry) next(data interface{}, historySize int) record {
+	entry.seq++
+	rec := record{seq: entry.seq, posted: entry.clock.Now(), data: data}
+	if entry.global != nil {
+		rec.global = entry.global.Add(1)
+	}
+	entry.active = rec.posted
+	if historySize > 0 {
+		if entry.memory != nil {
+			rec.size = entry.memory.size(data)
+		}
+		if len(entry.history) >= historySize {
+			entry.discard(len(entry.history) - historySize + 1)
+		}
+		entry.history = append(entry.history, rec)
+		entry.retained += rec.size
+		entry.memory.charge(rec.size)
+		entry.trim()
+	}
+
+	return rec
+}
+
+// Drop the oldest count records of the history. Must be called with the entry
+// locked
+func (entry *eventEntry) discard(count int) {
+	for _, rec := range entry.history[:count] {
+		entry.retained -= rec.size
+		entry.memory.charge(-rec.size)
+	}
+	entry.history = append(entry.history[:0], entry.history[count:]...)
+}
+
 type Notifier struct {
-	events map[string][]chan interface{}
+	shards [eventShards]eventShard
+	// Held for reading along with a shard's lock by operations on a single
+	// event and for writing by those spanning all of them
 	sync.RWMutex
+	eventTTL  time.Duration
+	autoPrune bool
+	pruneStop chan struct{}
+
+	noSubscribers NoSubscribersPolicy
+	pendingLimit  int
+	pending       map[string][]interface{}
+	pendingLock   sync.Mutex
+
+	tickers    map[string]chan struct{}
+	tickerLock sync.Mutex
+
+	epoch       int64
+	historySize int
+	clock       Clock
+
+	globalSequence  bool
+	globalSeq       atomic.Uint64
+	orderedDelivery bool
+
+	fanOutWorkers   int
+	fanOutThreshold int
+	deadlineReserve time.Duration
+
+	rateLimits map[string]*rateLimit
+	topics     map[string]*topicLock
+
+	normalizers  map[string][]func(data interface{}) (interface{}, error)
+	rejectNil    map[string]bool
+	rejectNilAll bool
+
+	subscriberLimit int
+	maxEvents       int
+	eventCount      atomic.Int64 // started events, counted against maxEvents
+	maxMemory       int
+	sizer           func(data interface{}) int
+	memory          *memoryBudget
+
+	samplers    atomic.Pointer[map[string]*sampler]
+	samplerLock sync.Mutex
+
+	mirror        *Notifier // see WithMirror
+	mirrorConfig  MirrorConfig
+	mirrorQueue   []mirrored
+	mirrorRunning bool
+	mirrorClosed  bool
+	mirrorLock    sync.Mutex
+
+	flows    map[uint64]flow // see Graph
+	flowID   uint64
+	flowLock sync.Mutex
+
+	aliases      atomic.Pointer[map[string]string]
+	onDeprecated func(old string, event string)
+	// Events sticky through an alias of theirs, and the aliases whose resume
+	// tokens still apply to their event (written with the notifier locked)
+	stickyAliases atomic.Pointer[map[string]bool]
+	resumable     map[string]bool
+	// Rate limits and topic concurrency configured for an alias only, by the
+	// event it names
+	aliasConfig atomic.Pointer[aliasedConfig]
+
+	lifecycle        map[string]*lifecycleHooks
+	lifecycleQueue   []func()
+	lifecycleRunning bool
+	lifecycleLock    sync.Mutex
+
+	stats counters
+
+	onError   func(event string, err error)
+	postHook  func(event string, data interface{})
+	copyCodec Codec
+	handlers  sync.WaitGroup
+	closed    bool
+
+	closePolicy ClosePolicy
+	closeDrain  time.Duration
+	closeState  atomic.Int32
+	// Posts in progress, counted under CloseDrain. drained is closed once
+	// they are down to zero while Close waits for them
+	inFlight     int
+	drained      chan struct{}
+	inFlightLock sync.Mutex
+
+	syncDispatch bool
+	diagnostics  *diagnostics
+	tracer       *subscriptionTracer
+	execTrace    bool // see WithExecutionTrace
+
+	selfTests    map[uint64]selfTest
+	selfTestLock sync.Mutex
+
+	journal      Journal
+	journalCodec Codec
+	journaled    map[string]bool
+
+	stickyEvents map[string]bool
+	sticky       map[string]*stickyValue
+	stickyLock   sync.Mutex
+	stickyStore  StickyStore
+	stickyCodec  Codec
+
+	replayed      map[string]bool
+	replayWaiters []*replayWaiter
+	replayLock    sync.Mutex
+
+	schedules     map[string]*schedule
+	scheduleLock  sync.Mutex
+	scheduleStore ScheduleStore
 }
 
-func NewNotifier() *Notifier {
-	return &Notifier{
-		events: make(map[string][]chan interface{}),
+func NewNotifier(options ...Option) *Notifier {
+	notifier := &Notifier{
+		epoch:        time.Now().UnixNano(),
+		clock:        SystemClock,
+		pendingLimit: DefaultPendingLimit,
+		pending:      make(map[string][]interface{}),
+		tickers:      make(map[string]chan struct{}),
+		schedules:    make(map[string]*schedule),
+		journaled:    make(map[string]bool),
+		stickyEvents: make(map[string]bool),
+		sticky:       make(map[string]*stickyValue),
+		replayed:     make(map[string]bool),
+		lifecycle:    make(map[string]*lifecycleHooks),
+		rateLimits:   make(map[string]*rateLimit),
+		topics:       make(map[string]*topicLock),
+		flows:        make(map[uint64]flow),
+		normalizers:  make(map[string][]func(data interface{}) (interface{}, error)),
+		selfTests:    make(map[uint64]selfTest),
+	}
+	for i := range notifier.shards {
+		notifier.shards[i].events = make(map[string]*eventEntry)
+	}
+	for _, option := range options {
+		option(notifier)
 	}
+	if notifier.maxMemory > 0 {
+		share := notifier.maxMemory
+		if notifier.maxEvents > 0 {
+			share /= notifier.maxEvents
+		}
+		notifier.memory = newMemoryBudget(notifier.maxMemory, share, notifier.sizer)
+	}
+	if notifier.stickyStore != nil {
+		notifier.restoreSticky()
+	}
+	if notifier.autoPrune {
+		notifier.pruneStop = make(chan struct{})
+		go notifier.runPrune()
+	}
+	return notifier
 }
 
-// Start observing the specified event via provided output channel
-func (notifier *Notifier) Start(event string, outputChan chan interface{}) {
-	notifier.Lock()
-	defer notifier.Unlock()
+// The shard holding event
+func (notifier *Notifier) shard(event string) *eventShard {
+	return &notifier.shards[shardIndex(event)]
+}
 
-	notifier.events[event] = append(notifier.events[event], outputChan)
+// The index of the shard holding event
+func shardIndex(event string) uint32 {
+	// FNV-1a
+	hash := uint32(2166136261)
+	for i := 0; i < len(event); i++ {
+		hash ^= uint32(event[i])
+		hash *= 16777619
+	}
+	return hash % eventShards
 }
 
-// Stop observing the specified event on the provided output channel
-func (notifier *Notifier) Stop(event string, outputChan chan interface{}) error {
-	notifier.Lock()
-	defer notifier.Unlock()
+// Lock the shard holding event for writing and the notifier for reading,
+// returning the shard and the name event is kept under (see Alias)
+func (notifier *Notifier) lockEvent(event string) (*eventShard, string) {
+	notifier.RLock()
+	event = notifier.canonical(event)
+	shard := notifier.shard(event)
+	shard.Lock()
+	return shard, event
+}
 
-	newArray := make([]chan interface{}, 0)
-	outChans, ok := notifier.events[event]
+func (notifier *Notifier) unlockEvent(shard *eventShard) {
+	shard.Unlock()
+	notifier.RUnlock()
+}
+
+// Start observing the specified event via provided output channel. The
+// returned Subscription can be used to stop or pause just this observer. The
+// channel stays open once the observer is stopped unless it was started
+// WithChannelOwnership. Nothing is started for a nil channel or notifier, in
+// which case the Subscription's Err reports why
+func (notifier *Notifier) Start(event string, outputChan chan interface{}, options ...SubscribeOption) *Subscription {
+	switch {
+	case notifier == nil:
+		return failedSubscription(event, ErrNilNotifier)
+	case outputChan == nil:
+		return failedSubscription(event, ErrNilChannel)
+	}
+	sub := &subscriber{outputChan: outputChan}
+	for _, option := range options {
+		option(sub)
+	}
+
+	shard, event := notifier.lockEvent(notifier.resolve(event))
+	defer notifier.unlockEvent(shard)
+
+	if err := notifier.admit(event, 1); err != nil {
+		if sub.owned {
+			close(outputChan)
+		}
+		return failedSubscription(event, err)
+	}
+	notifier.start(event, sub)
+	return &Subscription{notifier: notifier, event: event, sub: sub}
+}
+
+// Add an observer to event, creating it if needed. Must be called with the
+// event's shard locked (see lockEvent)
+func (notifier *Notifier) start(event string, sub *subscriber) *eventEntry {
+	shard := notifier.shard(event)
+	entry, ok := shard.events[event]
 	if !ok {
-		return ErrEventNotFound
+		// Counted by admit
+		entry = notifier.newEntry()
+		shard.events[event] = entry
 	}
-	for _, ch := range outChans {
-		if ch != outputChan {
-			newArray = append(newArray, ch)
-		} else {
-			close(ch)
+	sub.done = make(chan struct{})
+	subs := entry.observers()
+	if notifier.diagnostics != nil {
+		notifier.diagnostics.checkStart(event, subs, sub)
+	}
+	for _, bind := range sub.binds {
+		bind(&Subscription{notifier: notifier, event: event, sub: sub})
+	}
+	sub.binds = nil
+	if notifier.tracer != nil {
+		notifier.tracer.start(event, sub)
+	}
+	entry.setObservers(append(subs[:len(subs):len(subs)], sub))
+	notifier.startTicker(event)
+	if len(subs) == 0 {
+		notifier.observed(event, true)
+	}
+
+	notifier.stickyLock.Lock()
+	if value := notifier.sticky[event]; value != nil {
+		go notifier.deliverSticky(event, sub, value)
+	}
+	notifier.stickyLock.Unlock()
+
+	notifier.pendingLock.Lock()
+	defer notifier.pendingLock.Unlock()
+
+	if pending := notifier.pending[event]; len(pending) > 0 {
+		delete(notifier.pending, event)
+		go notifier.flushPending(event, entry, sub, pending)
+	}
+
+	return entry
+}
+
+// Forget the entry of event, giving back the memory its history holds. Must
+// be called with the event's shard locked
+func (notifier *Notifier) removeEntry(shard *eventShard, event string, entry *eventEntry) {
+	delete(shard.events, event)
+	notifier.eventCount.Add(-1)
+	entry.release()
+}
+
+func (notifier *Notifier) newEntry() *eventEntry {
+	now := notifier.clock.Now()
+	entry := &eventEntry{created: now, active: now, clock: notifier.clock, memory: notifier.memory}
+	if notifier.globalSequence {
+		entry.global = &notifier.globalSeq
+	}
+	return entry
+}
+
+// Deliver notifications that were buffered before the first observer started.
+// Posts made while the flush is in progress may be delivered ahead of them
+func (notifier *Notifier) flushPending(event string, entry *eventEntry, sub *subscriber, pending []interface{}) {
+	if !sub.accepts(backgroundPost.severity) {
+		return
+	}
+	for _, data := range pending {
+		if !sub.send(notifier.value(event, sub, entry.record(data, notifier.historySize), backgroundPost)) {
+			return
+		}
+	}
+}
+
+// The entry for event, if it has been started
+func (notifier *Notifier) lookup(event string) (*eventEntry, bool) {
+	shard := notifier.shard(event)
+	shard.RLock()
+	defer shard.RUnlock()
+
+	entry, ok := shard.events[event]
+	return entry, ok
+}
+
+// The value delivered to sub for a posted notification
+func (notifier *Notifier) value(event string, sub *subscriber, rec record, pc postContext) interface{} {
+	switch {
+	case sub.watch:
+		return rec
+	case sub.handler != nil:
+		return invocation{
+			postContext: pc,
+			envelope:    pc.envelope(event, rec),
+			data:        rec.data,
 		}
+	case sub.envelopes:
+		envelope := pc.envelope(event, rec)
+		envelope.Data = rec.data
+		return envelope
 	}
-	notifier.events[event] = newArray
+	return rec.data
+}
 
+// A value ready to be sent to an observer
+type delivery struct {
+	sub   *subscriber
+	event string
+	data  interface{} // as posted, for DeliveryErrors
+	value interface{}
+}
+
+// Send the value, blocking until it is received
+func (notifier *Notifier) send(d delivery) bool {
+	return notifier.watchSend(d, func() bool {
+		return d.sub.send(d.value)
+	})
+}
+
+// Run send, watched for slow observers if diagnostics are enabled and timed
+// if the observer is traced
+func (notifier *Notifier) watchSend(d delivery, send func() bool) bool {
+	if d.sub.trace != nil {
+		defer d.sub.trace.delivered(notifier.clock.Now())
+	}
+	if notifier.diagnostics == nil {
+		return send()
+	}
+	return notifier.diagnostics.watchSend(d.event, notifier.clock, send)
+}
+
+// Record a post to event and hand the values for its observers to deliver.
+// Posts to events without observers are handled according to the notifier's
+// no subscribers policy. Observers started or stopped while the values are
+// delivered don't affect the post
+func (notifier *Notifier) post(event string, data interface{}, pc postContext, deliver func(deliveries []delivery) error) error {
+	if notifier == nil {
+		return ErrNilNotifier
+	}
+	event = notifier.resolve(event)
+	if !pc.admitted {
+		if notifier.closePolicy == CloseDrain {
+			notifier.enterPost()
+			defer notifier.leavePost()
+		}
+		if ok, err := notifier.accepting(event, data); !ok {
+			return err
+		}
+	}
+	var endTask func()
+	pc.ctx, endTask = notifier.startTask(pc.ctx, event)
+	defer endTask()
+	if notifier.diagnostics != nil && !pc.admitted {
+		notifier.diagnostics.checkPost(event)
+	}
+	if !pc.replay {
+		var err error
+		if data, err = notifier.normalize(event, data); err != nil {
+			return err
+		}
+	}
+	if err := notifier.checkNil(event, data); err != nil {
+		return err
+	}
+	if !pc.admitted {
+		if ok, err := notifier.allow(event, pc); !ok {
+			return err
+		}
+	}
+	if !pc.replay && notifier.skippable(event) {
+		if entry, ok := notifier.lookup(event); !ok || len(entry.observers()) == 0 {
+			notifier.stats.shortCircuited.Add(1)
+			return notifier.postNoSubscribers(event, ok, data)
+		}
+	}
+	payload, err := notifier.snapshot(data)
+	if err != nil {
+		return err
+	}
+	if data, err = notifier.restore(payload, data); err != nil {
+		return err
+	}
+	if notifier.postHook != nil {
+		notifier.postHook(event, data)
+	}
+	notifier.sample(event, data)
+	notifier.mirrorPost(event, data)
+	if err := notifier.persist(event, data, !pc.replay); err != nil {
+		return err
+	}
+	sticky := notifier.isSticky(event)
+
+	entry, ok := notifier.lookup(event)
+	if !ok || (notifier.noSubscribers == NoSubscribersBuffer && len(entry.observers()) == 0) {
+		if sticky {
+			notifier.keepSticky(event, record{posted: notifier.clock.Now(), data: data}, payload)
+			return nil
+		}
+		return notifier.postNoSubscribers(event, ok, data)
+	}
+
+	if notifier.orderedDelivery {
+		entry.deliverLock.Lock()
+		defer entry.deliverLock.Unlock()
+	}
+	rec, subs := entry.publish(data, notifier.historySize)
+	notifier.keepSticky(event, rec, payload)
+	if len(subs) == 0 {
+		if sticky {
+			return nil
+		}
+		return notifier.postNoSubscribers(event, ok, data)
+	}
+	deliveries := make([]delivery, 0, len(subs))
+	for _, sub := range subs {
+		if !sub.accepts(pc.severity) {
+			continue
+		}
+		subRec := rec
+		if subRec.data, err = notifier.restore(payload, rec.data); err != nil {
+			// The others still get their copies
+			notifier.stats.copyFailed.Add(1)
+			sub.report(event, rec.data, err)
+			continue
+		}
+		deliveries = append(deliveries, delivery{sub: sub, event: event, data: subRec.data, value: notifier.value(event, sub, subRec, pc)})
+	}
+
+	defer notifier.startRegion(pc.ctx, "notify.deliver")()
+	return deliver(deliveries)
+}
+
+// Journal a post, unless journal is false, and save it to the sticky store,
+// failing it if either fails or it doesn't fit the memory budget
+func (notifier *Notifier) persist(event string, data interface{}, journal bool) error {
+	if journal {
+		if err := notifier.writeJournal(event, data); err != nil {
+			return err
+		}
+	}
+	if err := notifier.fitSticky(event, data); err != nil {
+		return err
+	}
+	return notifier.saveSticky(event, data)
+}
+
+func (notifier *Notifier) deliverBlocking(deliveries []delivery) error {
+	if notifier.fanOutWorkers > 1 && len(deliveries) >= notifier.fanOutThreshold {
+		fanOut(notifier.fanOutWorkers, len(deliveries), func(i int) {
+			notifier.send(deliveries[i])
+		})
+		return nil
+	}
+
+	for _, d := range deliveries {
+		notifier.send(d)
+	}
 	return nil
 }
 
-// Stop observing the specified event on all channels
-func (notifier *Notifier) StopAll(event string) error {
-	notifier.Lock()
-	defer notifier.Unlock()
+// Deliver to every ready observer first, then wait on the blocked ones
+// concurrently until ctx is done. Returns false if some observers were skipped
+func (notifier *Notifier) deliverContext(ctx context.Context, deliveries []delivery) bool {
+	var blocked []delivery
+	for _, d := range deliveries {
+		if !d.sub.trySend(d.value) {
+			blocked = append(blocked, d)
+		}
+	}
+	if len(blocked) == 0 {
+		return true
+	}
 
-	outChans, ok := notifier.events[event]
+	workers := len(blocked)
+	if notifier.fanOutWorkers > 0 {
+		workers = notifier.fanOutWorkers
+	}
+
+	var timedOut atomic.Bool
+	fanOut(workers, len(blocked), func(i int) {
+		d := blocked[i]
+		sent := notifier.watchSend(d, func() bool {
+			return d.sub.sendContext(ctx, d.value)
+		})
+		if !sent {
+			d.sub.report(d.event, d.data, ctx.Err())
+			timedOut.Store(true)
+		}
+	})
+
+	return !timedOut.Load()
+}
+
+// Run fn for every index in [0, count) on up to workers goroutines
+func fanOut(workers int, count int, fn func(i int)) {
+	if workers > count {
+		workers = count
+	}
+	if workers <= 1 {
+		for i := 0; i < count; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= count {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Whether a post to event can be handed to the no subscribers policy as soon
+// as it turns out to have no observers: nothing records it, holds it for
+// later observers or sees it on the way
+func (notifier *Notifier) skippable(event string) bool {
+	if notifier.postHook != nil || notifier.historySize > 0 || notifier.eventTTL > 0 ||
+		notifier.noSubscribers == NoSubscribersBuffer ||
+		notifier.isSticky(event) || notifier.journaled[event] || notifier.mirrors(event) {
+		return false
+	}
+	_, sampled := notifier.sampler(event)
+	return !sampled
+}
+
+// Handle a post to an event without observers according to the notifier's
+// policy. found reports whether the event has ever been started
+func (notifier *Notifier) postNoSubscribers(event string, found bool, data interface{}) error {
+	notifier.stats.unobserved.Add(1)
+	switch notifier.noSubscribers {
+	case NoSubscribersIgnore:
+		return nil
+	case NoSubscribersError:
+		return ErrNoSubscribers
+	case NoSubscribersBuffer:
+		notifier.pendingLock.Lock()
+		defer notifier.pendingLock.Unlock()
+
+		if len(notifier.pending[event]) >= notifier.pendingLimit {
+			return ErrPendingFull
+		}
+		notifier.pending[event] = append(notifier.pending[event], data)
+		return nil
+	}
+
+	if !found {
+		return ErrEventNotFound
+	}
+	return nil
+}
+
+// Stop observing the specified event on the provided output channel, closing
+// it if it was started WithChannelOwnership
+//
+// Deprecated: use Unsubscribe on the Subscription returned by Start, or
+// SubscriptionFor to find it for code that didn't keep it
+func (notifier *Notifier) Stop(event string, outputChan chan interface{}) error {
+	if notifier == nil {
+		return ErrNilNotifier
+	}
+	shard, event := notifier.lockEvent(notifier.resolve(event))
+	defer notifier.unlockEvent(shard)
+
+	return notifier.stop(event, func(sub *subscriber) bool {
+		return sub.outputChan == outputChan
+	})
+}
+
+// Remove and close the observers of event for which match returns true. Must
+// be called with the event's shard or the notifier locked
+func (notifier *Notifier) stop(event string, match func(sub *subscriber) bool) error {
+	newArray := make([]*subscriber, 0)
+	entry, ok := notifier.shard(event).events[event]
 	if !ok {
 		return ErrEventNotFound
 	}
-	for _, ch := range outChans {
-		close(ch)
+	var stopped []*subscriber
+	for _, sub := range entry.observers() {
+		if !match(sub) {
+			newArray = append(newArray, sub)
+		} else {
+			stopped = append(stopped, sub)
+		}
+	}
+	entry.setObservers(newArray)
+	closeObservers(stopped, newArray)
+	if len(newArray) == 0 {
+		if len(stopped) > 0 {
+			notifier.observed(event, false)
+		}
+		notifier.stopTicker(event)
+		entry.Lock()
+		entry.active = notifier.clock.Now()
+		entry.Unlock()
 	}
-	delete(notifier.events, event)
 
 	return nil
 }
 
-// Post a notification (arbitrary data) to the specified event
-func (notifier *Notifier) Post(event string, data interface{}) error {
-	notifier.RLock()
-	defer notifier.RUnlock()
+// Stop observing the specified event on all channels, closing those started
+// WithChannelOwnership
+func (notifier *Notifier) StopAll(event string) error {
+	if notifier == nil {
+		return ErrNilNotifier
+	}
+	shard, event := notifier.lockEvent(notifier.resolve(event))
+	defer notifier.unlockEvent(shard)
 
-	outChans, ok := notifier.events[event]
+	entry, ok := shard.events[event]
 	if !ok {
 		return ErrEventNotFound
 	}
-	for _, outputChan := range outChans {
-		outputChan <- data
+	if subs := entry.observers(); len(subs) > 0 {
+		closeObservers(subs, nil)
+		notifier.observed(event, false)
 	}
+	entry.setObservers(nil)
+	notifier.removeEntry(shard, event, entry)
+	notifier.stopTicker(event)
 
 	return nil
 }
 
+// Post a notification (arbitrary data) to the specified event. nil is
+// delivered like any other payload unless rejected with WithRejectNil.
+// Posts to events without observers return as soon as the rate limit allows
+// them, with the result of the no subscribers policy (see WithNoSubscribers),
+// unless the event is sticky, journaled, sampled or mirrored or the notifier
+// keeps history, an event TTL or a post hook
+func (notifier *Notifier) Post(event string, data interface{}) error {
+	return notifier.post(event, data, backgroundPost, notifier.deliverBlocking)
+}
+
+// Post a notification to every observer of the specified event except the
+// provided output channel. Bridges use this to inject remote notifications
+// without observing them a second time
+func (notifier *Notifier) PostExcept(event string, data interface{}, exclude chan interface{}) error {
+	return notifier.post(event, data, backgroundPost, func(deliveries []delivery) error {
+		included := deliveries[:0]
+		for _, d := range deliveries {
+			if d.sub.outputChan != exclude {
+				included = append(included, d)
+			}
+		}
+		return notifier.deliverBlocking(included)
+	})
+}
+
 // Post a notification to the specified event using the provided timeout for
-// any output channels that are blocking
+// any output channels that are blocking. Ready channels are delivered to first
+// and the blocked ones then share the timeout, so a slow observer doesn't
+// delay the others
 func (notifier *Notifier) PostTimeout(event string, data interface{}, timeout time.Duration) error {
-	notifier.RLock()
-	defer notifier.RUnlock()
+	if notifier == nil {
+		return ErrNilNotifier
+	}
+	ctx, cancel := withClockDeadline(context.Background(), notifier.clock, notifier.clock.Now().Add(timeout))
+	defer cancel()
 
-	var err error = nil
+	deadline, _ := ctx.Deadline()
+	pc := postContext{ctx: context.Background(), deadline: deadline, severity: SeverityInfo}
+	return notifier.post(event, data, pc, func(deliveries []delivery) error {
+		if !notifier.deliverContext(ctx, deliveries) {
+			return ErrPostTimedOut
+		}
+		return nil
+	})
+}
 
-	outChans, ok := notifier.events[event]
-	if !ok {
-		return ErrEventNotFound
+// Post a notification to the specified event, giving up on output channels
+// that are still blocking once ctx is done, or earlier WithDeadlineReserve.
+// Callback observers receive a context derived from ctx
+func (notifier *Notifier) PostContext(ctx context.Context, event string, data interface{}) error {
+	if notifier == nil {
+		return ErrNilNotifier
 	}
-	for _, outputChan := range outChans {
-		select {
-		case outputChan <- data:
-		case <-time.After(timeout):
-			err = ErrPostTimedOut
-		}
+	budget := ctx
+	deadline, ok := ctx.Deadline()
+	if ok && notifier.deadlineReserve > 0 {
+		deadline = deadline.Add(-notifier.deadlineReserve)
+		var cancel context.CancelFunc
+		budget, cancel = withClockDeadline(ctx, notifier.clock, deadline)
+		defer cancel()
 	}
 
-	return err
+	pc := postContext{ctx: ctx, deadline: deadline, severity: SeverityInfo, source: SourceFromContext(ctx)}
+	return notifier.post(event, data, pc, func(deliveries []delivery) error {
+		if !notifier.deliverContext(budget, deliveries) {
+			return budget.Err()
+		}
+		return nil
+	})
 }
 
 // Post a notification to the specified event using a function to generate the
 // data. State can be passed to the function for tracking purposes. Posting will
 // stop if an error is encountered so it's possible some channels may receive
-// the event and others will miss out
+// the event and others will miss out. Every value generated is copied like
+// posted data (see WithCopyOnPost); the one generated for the first observer
+// is journaled and kept for sticky events. Without observers a single value
+// is generated and posted like Post's, unless nothing would record it
 func (notifier *Notifier) PostGenerateData(event string, state interface{}, generator func(s interface{}) (interface{}, error)) error {
-	notifier.RLock()
-	defer notifier.RUnlock()
-
-	outChans, ok := notifier.events[event]
-	if !ok {
-		return ErrEventNotFound
+	if notifier == nil {
+		return ErrNilNotifier
+	}
+	event = notifier.resolve(event)
+	if notifier.closePolicy == CloseDrain {
+		notifier.enterPost()
+		defer notifier.leavePost()
+	}
+	if !notifier.admits() {
+		// Posts journaled on close need a value to journal
+		var data interface{}
+		if notifier.closePolicy == CloseJournal && notifier.journal != nil {
+			var err error
+			if data, err = generator(state); err != nil {
+				return err
+			}
+		}
+		return notifier.closedPost(event, data)
+	}
+	if notifier.diagnostics != nil {
+		notifier.diagnostics.checkPost(event)
 	}
-	for _, outputChan := range outChans {
+	if ok, err := notifier.allow(event, backgroundPost); !ok {
+		return err
+	}
+	entry, ok := notifier.lookup(event)
+	var subs []*subscriber
+	if ok {
+		subs = entry.observers()
+	}
+	if len(subs) == 0 {
+		if notifier.skippable(event) {
+			notifier.stats.shortCircuited.Add(1)
+			return notifier.postNoSubscribers(event, ok, nil)
+		}
 		data, err := generator(state)
 		if err != nil {
 			return err
 		}
+		pc := backgroundPost
+		pc.admitted = true
+		return notifier.post(event, data, pc, notifier.deliverBlocking)
+	}
+	if notifier.orderedDelivery {
+		entry.deliverLock.Lock()
+		defer entry.deliverLock.Unlock()
+	}
+	first := true
+	for _, sub := range subs {
+		if !sub.accepts(backgroundPost.severity) {
+			continue
+		}
+		data, payload, err := notifier.generate(event, state, generator)
+		if err != nil {
+			return err
+		}
+		if first {
+			if err := notifier.persist(event, data, true); err != nil {
+				return err
+			}
+		}
+		if notifier.postHook != nil {
+			notifier.postHook(event, data)
+		}
+		notifier.sample(event, data)
+		notifier.mirrorPost(event, data)
 
-		outputChan <- data
+		rec := entry.record(data, notifier.historySize)
+		if first {
+			notifier.keepSticky(event, rec, payload)
+			first = false
+		}
+		sub.send(notifier.value(event, sub, rec, backgroundPost))
 	}
 
 	return nil
 }
+
+// Generate a value for PostGenerateData, normalized and copied like the data
+// of Post, along with its snapshot
+func (notifier *Notifier) generate(event string, state interface{}, generator func(s interface{}) (interface{}, error)) (interface{}, []byte, error) {
+	data, err := generator(state)
+	if err == nil {
+		data, err = notifier.normalize(event, data)
+	}
+	if err == nil {
+		err = notifier.checkNil(event, data)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := notifier.snapshot(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data, err = notifier.restore(payload, data); err != nil {
+		return nil, nil, err
+	}
+	return data, payload, nil
+}
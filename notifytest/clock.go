@@ -0,0 +1,141 @@
+package notifytest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// FakeClock is a notify.Clock whose time only changes through Advance and
+// Set. Timers and tickers fire, in order, as the time passes their deadlines.
+// Like real ones, their channels hold a single pending value and further
+// ticks are dropped until it is received
+type FakeClock struct {
+	now     time.Time
+	waiters []*fakeTimer
+	sync.Mutex
+}
+
+// NewFakeClock returns a clock stopped at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (clock *FakeClock) Now() time.Time {
+	clock.Lock()
+	defer clock.Unlock()
+
+	return clock.now
+}
+
+func (clock *FakeClock) NewTimer(d time.Duration) notify.Timer {
+	return clock.add(d, 0)
+}
+
+func (clock *FakeClock) NewTicker(d time.Duration) notify.Ticker {
+	if d <= 0 {
+		panic("notifytest: non-positive interval for NewTicker")
+	}
+	return fakeTicker{clock.add(d, d)}
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker due by
+// then
+func (clock *FakeClock) Advance(d time.Duration) {
+	clock.Set(clock.Now().Add(d))
+}
+
+// Set moves the clock to now, firing every timer and ticker due by then.
+// Moving it backwards fires nothing
+func (clock *FakeClock) Set(now time.Time) {
+	clock.Lock()
+	defer clock.Unlock()
+
+	for {
+		sort.Slice(clock.waiters, func(i, j int) bool {
+			return clock.waiters[i].deadline.Before(clock.waiters[j].deadline)
+		})
+		if len(clock.waiters) == 0 || clock.waiters[0].deadline.After(now) {
+			break
+		}
+
+		timer := clock.waiters[0]
+		clock.now = timer.deadline
+		select {
+		case timer.c <- timer.deadline:
+		default:
+		}
+		if timer.period > 0 {
+			timer.deadline = timer.deadline.Add(timer.period)
+		} else {
+			clock.waiters = clock.waiters[1:]
+		}
+	}
+	if now.After(clock.now) {
+		clock.now = now
+	}
+}
+
+// Timers returns the number of timers and tickers waiting to fire, so tests
+// can wait for the code under test to start one before advancing the clock
+func (clock *FakeClock) Timers() int {
+	clock.Lock()
+	defer clock.Unlock()
+
+	return len(clock.waiters)
+}
+
+func (clock *FakeClock) add(d time.Duration, period time.Duration) *fakeTimer {
+	clock.Lock()
+	defer clock.Unlock()
+
+	timer := &fakeTimer{
+		clock:    clock,
+		c:        make(chan time.Time, 1),
+		deadline: clock.now.Add(d),
+		period:   period,
+	}
+	if d <= 0 && period == 0 {
+		timer.c <- clock.now
+		return timer
+	}
+	clock.waiters = append(clock.waiters, timer)
+	return timer
+}
+
+// A timer or, with a period, ticker of a FakeClock
+type fakeTimer struct {
+	clock    *FakeClock
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration
+}
+
+func (timer *fakeTimer) C() <-chan time.Time {
+	return timer.c
+}
+
+// Stop reports whether the timer was still waiting to fire
+func (timer *fakeTimer) Stop() bool {
+	clock := timer.clock
+	clock.Lock()
+	defer clock.Unlock()
+
+	for i, waiter := range clock.waiters {
+		if waiter == timer {
+			clock.waiters = append(clock.waiters[:i], clock.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (ticker fakeTicker) Stop() {
+	ticker.fakeTimer.Stop()
+}
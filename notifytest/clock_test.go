@@ -0,0 +1,126 @@
+package notifytest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-c:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := notifytest.NewFakeClock(start)
+	timer := clock.NewTimer(time.Second)
+
+	clock.Advance(999 * time.Millisecond)
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("timer fired before its deadline")
+	}
+	clock.Advance(time.Millisecond)
+	if at, ok := fired(timer.C()); !ok || !at.Equal(start.Add(time.Second)) {
+		t.Fatalf("timer fired %v, %v, want at its deadline", at, ok)
+	}
+	if clock.Timers() != 0 {
+		t.Fatal("fired timer still waiting")
+	}
+	if timer.Stop() {
+		t.Fatal("Stop reported a fired timer as waiting")
+	}
+}
+
+func TestFakeClockStop(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	timer := clock.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Fatal("Stop didn't report the timer as waiting")
+	}
+	clock.Advance(time.Minute)
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("stopped timer fired")
+	}
+}
+
+func TestFakeClockExpiredTimer(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+
+	if _, ok := fired(clock.NewTimer(0).C()); !ok {
+		t.Fatal("timer without a duration didn't fire right away")
+	}
+	if clock.Timers() != 0 {
+		t.Fatal("expired timer waiting")
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := notifytest.NewFakeClock(start)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	clock.Advance(time.Second)
+	if at, ok := fired(ticker.C()); !ok || !at.Equal(start.Add(time.Second)) {
+		t.Fatalf("ticked %v, %v, want after a second", at, ok)
+	}
+	// Ticks nobody received are dropped, leaving the first pending
+	clock.Advance(3 * time.Second)
+	if at, ok := fired(ticker.C()); !ok || !at.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("ticked %v, %v, want the first tick missed", at, ok)
+	}
+	if _, ok := fired(ticker.C()); ok {
+		t.Fatal("dropped ticks delivered")
+	}
+	if now := clock.Now(); !now.Equal(start.Add(4 * time.Second)) {
+		t.Fatalf("clock at %v, want 4s past the start", now)
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Second)
+	if _, ok := fired(ticker.C()); ok {
+		t.Fatal("stopped ticker ticked")
+	}
+}
+
+func TestFakeClockFiresInOrder(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	late, early := clock.NewTimer(2*time.Second), clock.NewTimer(time.Second)
+
+	clock.Advance(time.Minute)
+	lateAt, _ := fired(late.C())
+	earlyAt, _ := fired(early.C())
+	if !earlyAt.Before(lateAt) {
+		t.Fatalf("timers fired at %v and %v, want each at its deadline", earlyAt, lateAt)
+	}
+}
+
+func TestFakeClockSetBackwards(t *testing.T) {
+	start := time.Unix(100, 0)
+	clock := notifytest.NewFakeClock(start)
+	timer := clock.NewTimer(time.Second)
+
+	clock.Set(start.Add(-time.Minute))
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("moving the clock backwards fired a timer")
+	}
+	if !clock.Now().Equal(start) {
+		t.Fatalf("clock at %v, want it left at %v", clock.Now(), start)
+	}
+}
+
+func TestFakeClockTickerPanicsWithoutInterval(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewTicker accepted a zero interval")
+		}
+	}()
+	notifytest.NewFakeClock(time.Unix(0, 0)).NewTicker(0)
+}
@@ -0,0 +1,174 @@
+// Package notifytest provides helpers for testing code built on notify.
+//
+// A Recorder captures every notification posted to a notifier so tests can
+// assert on what was posted without starting observers of their own:
+//
+//	func TestSignup(t *testing.T) {
+//		notifier, recorder := notifytest.New(t)
+//		signup(notifier, "ada")
+//		post := recorder.ExpectEvent(t, "user_created", time.Second)
+//		if post.Data != "ada" {
+//			t.Fatalf("unexpected user %v", post.Data)
+//		}
+//	}
+//
+// FakeClock is a notify.Clock that only moves when told to, for testing
+// timeouts, TTLs and rate limits deterministically.
+package notifytest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// Post is a notification captured by a Recorder
+type Post struct {
+	Seq   int // position among all the posts recorded, starting at 1
+	Event string
+	Data  interface{}
+	Time  time.Time
+}
+
+// Recorder captures the notifications posted to the notifiers it is attached
+// to with Option
+type Recorder struct {
+	clock notify.Clock
+	posts []Post
+	// Index into posts of the next post ExpectEvent looks at, by event
+	cursors map[string]int
+	changed chan struct{} // closed and replaced on every post
+	sync.Mutex
+}
+
+// NewRecorder returns an empty recorder timestamping posts with clock, or
+// notify.SystemClock if it is nil
+func NewRecorder(clock notify.Clock) *Recorder {
+	if clock == nil {
+		clock = notify.SystemClock
+	}
+	return &Recorder{
+		clock:   clock,
+		cursors: make(map[string]int),
+		changed: make(chan struct{}),
+	}
+}
+
+// New returns a notifier recording its posts to a new Recorder. The notifier
+// is closed once the test finishes
+func New(t testing.TB, options ...notify.Option) (*notify.Notifier, *Recorder) {
+	recorder := NewRecorder(nil)
+	notifier := notify.NewNotifier(append(options, recorder.Option())...)
+	t.Cleanup(func() {
+		notifier.Close()
+	})
+	return notifier, recorder
+}
+
+// NewSync is like New, dispatching to Handlers on the posting goroutine (see
+// notify.WithSyncDispatch) so handlers have run by the time Post returns
+func NewSync(t testing.TB, options ...notify.Option) (*notify.Notifier, *Recorder) {
+	return New(t, append(options, notify.WithSyncDispatch())...)
+}
+
+// Option attaches the recorder to a notifier
+func (recorder *Recorder) Option() notify.Option {
+	return notify.WithPostHook(recorder.record)
+}
+
+func (recorder *Recorder) record(event string, data interface{}) {
+	recorder.Lock()
+	defer recorder.Unlock()
+
+	recorder.posts = append(recorder.posts, Post{
+		Seq:   len(recorder.posts) + 1,
+		Event: event,
+		Data:  data,
+		Time:  recorder.clock.Now(),
+	})
+	close(recorder.changed)
+	recorder.changed = make(chan struct{})
+}
+
+// Posts returns every recorded post, oldest first
+func (recorder *Recorder) Posts() []Post {
+	recorder.Lock()
+	defer recorder.Unlock()
+
+	return append([]Post(nil), recorder.posts...)
+}
+
+// Events returns the recorded posts to event, oldest first
+func (recorder *Recorder) Events(event string) []Post {
+	recorder.Lock()
+	defer recorder.Unlock()
+
+	var posts []Post
+	for _, post := range recorder.posts {
+		if post.Event == event {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+// Reset discards every recorded post
+func (recorder *Recorder) Reset() {
+	recorder.Lock()
+	defer recorder.Unlock()
+
+	recorder.posts = nil
+	recorder.cursors = make(map[string]int)
+}
+
+// ExpectEvent waits up to within for a post to event and fails the test if
+// there is none. Every call returns the post following the one returned by
+// the previous call for the same event, so consecutive calls step through
+// the posts in order
+func (recorder *Recorder) ExpectEvent(t testing.TB, event string, within time.Duration) Post {
+	t.Helper()
+
+	post, ok := recorder.next(event, within)
+	if !ok {
+		t.Fatalf("notifytest: no %q event posted within %v", event, within)
+	}
+	return post
+}
+
+// ExpectNoEvent fails the test if event is posted within the given time,
+// apart from posts already returned by ExpectEvent
+func (recorder *Recorder) ExpectNoEvent(t testing.TB, event string, within time.Duration) {
+	t.Helper()
+
+	if post, ok := recorder.next(event, within); ok {
+		t.Fatalf("notifytest: unexpected %q event posted: %#v", event, post.Data)
+	}
+}
+
+// Wait up to within for the next post to event
+func (recorder *Recorder) next(event string, within time.Duration) (Post, bool) {
+	timer := time.NewTimer(within)
+	defer timer.Stop()
+
+	for {
+		recorder.Lock()
+		for i := recorder.cursors[event]; i < len(recorder.posts); i++ {
+			if recorder.posts[i].Event == event {
+				recorder.cursors[event] = i + 1
+				recorder.Unlock()
+				return recorder.posts[i], true
+			}
+		}
+		recorder.cursors[event] = len(recorder.posts)
+		changed := recorder.changed
+		recorder.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return Post{}, false
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package notifytest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+// fatalRecorder catches the failures reported to it instead of failing the
+// test
+type fatalRecorder struct {
+	testing.TB
+	failure string
+}
+
+func (tb *fatalRecorder) Helper() {}
+
+func (tb *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	tb.failure = fmt.Sprintf(format, args...)
+}
+
+func TestRecorderRecordsPosts(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	recorder := notifytest.NewRecorder(clock)
+	notifier := notify.NewNotifier(recorder.Option())
+	defer notifier.Close()
+
+	notifier.Post("orders", 1)
+	clock.Advance(time.Second)
+	notifier.Post("payments", 2)
+	notifier.Post("orders", 3)
+
+	posts := recorder.Posts()
+	if len(posts) != 3 {
+		t.Fatalf("recorded %d posts, want 3", len(posts))
+	}
+	for i, want := range []notifytest.Post{
+		{Seq: 1, Event: "orders", Data: 1, Time: time.Unix(0, 0)},
+		{Seq: 2, Event: "payments", Data: 2, Time: time.Unix(1, 0)},
+		{Seq: 3, Event: "orders", Data: 3, Time: time.Unix(1, 0)},
+	} {
+		if got := posts[i]; got.Seq != want.Seq || got.Event != want.Event || got.Data != want.Data || !got.Time.Equal(want.Time) {
+			t.Fatalf("post %d recorded as %+v, want %+v", i, got, want)
+		}
+	}
+	if orders := recorder.Events("orders"); len(orders) != 2 || orders[0].Data != 1 || orders[1].Data != 3 {
+		t.Fatalf("recorded %+v for orders, want 1 and 3", orders)
+	}
+
+	recorder.Reset()
+	if posts := recorder.Posts(); len(posts) != 0 {
+		t.Fatalf("recorded %+v after Reset", posts)
+	}
+}
+
+func TestExpectEventSteps(t *testing.T) {
+	notifier, recorder := notifytest.New(t)
+
+	notifier.Post("orders", 1)
+	notifier.Post("payments", 2)
+	notifier.Post("orders", 3)
+	for _, want := range []interface{}{1, 3} {
+		if post := recorder.ExpectEvent(t, "orders", time.Second); post.Data != want {
+			t.Fatalf("expected %v, want %v", post.Data, want)
+		}
+	}
+	recorder.ExpectNoEvent(t, "orders", 10*time.Millisecond)
+	if post := recorder.ExpectEvent(t, "payments", time.Second); post.Data != 2 {
+		t.Fatalf("expected %v, want 2", post.Data)
+	}
+}
+
+func TestExpectEventWaits(t *testing.T) {
+	notifier, recorder := notifytest.New(t)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		notifier.Post("orders", 1)
+	}()
+	if post := recorder.ExpectEvent(t, "orders", time.Second); post.Data != 1 {
+		t.Fatalf("expected %v, want 1", post.Data)
+	}
+}
+
+func TestExpectEventFails(t *testing.T) {
+	notifier, recorder := notifytest.New(t)
+
+	tb := &fatalRecorder{TB: t}
+	recorder.ExpectEvent(tb, "orders", 10*time.Millisecond)
+	if tb.failure == "" {
+		t.Fatal("ExpectEvent passed without a post")
+	}
+
+	notifier.Post("orders", 1)
+	tb = &fatalRecorder{TB: t}
+	recorder.ExpectNoEvent(tb, "orders", 10*time.Millisecond)
+	if tb.failure == "" {
+		t.Fatal("ExpectNoEvent passed despite a post")
+	}
+}
+
+func TestNewSyncRunsHandlers(t *testing.T) {
+	notifier, recorder := notifytest.NewSync(t)
+
+	handled := false
+	notifier.StartFunc("orders", func(ctx context.Context, data interface{}) error {
+		handled = true
+		return nil
+	})
+	notifier.Post("orders", 1)
+	if !handled {
+		t.Fatal("handler hadn't run by the time Post returned")
+	}
+	recorder.ExpectEvent(t, "orders", 0)
+}
@@ -0,0 +1,369 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrInvalidPipeline = errors.New("Invalid pipeline")
+
+// PipelineConfig declares a pipeline moving notifications from one event to
+// another through a sequence of stages. Pipelines are usually loaded from a
+// JSON file with LoadPipelines:
+//
+//	[{
+//	  "name": "order-alerts",
+//	  "from": "orders",
+//	  "to": "alerts",
+//	  "stages": [
+//	    {"filter": "large"},
+//	    {"map": "summary"},
+//	    {"batch": {"size": 100, "interval": "5s"}},
+//	    {"route": "by-region"}
+//	  ]
+//	}]
+//
+// Filters, maps and routes name functions registered in PipelineFuncs
+type PipelineConfig struct {
+	Name   string        `json:"name"`
+	From   string        `json:"from"`
+	To     string        `json:"to"` // destination unless a route stage picks one
+	Stages []StageConfig `json:"stages"`
+}
+
+// StageConfig declares a single pipeline stage. Exactly one field must be set
+type StageConfig struct {
+	// Filter drops notifications for which the named filter returns false
+	Filter string `json:"filter,omitempty"`
+	// Map replaces notifications with the result of the named map. Errors
+	// drop the notification and are reported to the error handler
+	Map string `json:"map,omitempty"`
+	// Batch groups notifications into []interface{} values
+	Batch *BatchConfig `json:"batch,omitempty"`
+	// Route posts notifications to the event returned by the named route
+	// instead of the pipeline's destination
+	Route string `json:"route,omitempty"`
+}
+
+// BatchConfig emits a batch once it holds Size notifications or Interval (a
+// time.ParseDuration string) has passed since the first one was added,
+// whichever comes first. Either may be omitted
+type BatchConfig struct {
+	Size     int    `json:"size,omitempty"`
+	Interval string `json:"interval,omitempty"`
+}
+
+// PipelineFuncs are the functions pipeline stages refer to by name
+type PipelineFuncs struct {
+	Filters map[string]func(data interface{}) bool
+	Maps    map[string]func(data interface{}) (interface{}, error)
+	Routes  map[string]func(data interface{}) string
+}
+
+// Router runs pipelines between the events of a notifier
+type Router struct {
+	notifier      *Notifier
+	subscriptions []*Subscription
+	flows         []func() // unregister from Graph
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+}
+
+// A notification moving through a pipeline
+type pipelineItem struct {
+	data interface{}
+	to   string
+}
+
+// A stage reading items from in until it is closed, then closing out
+type pipelineStage func(in <-chan pipelineItem, out chan<- pipelineItem)
+
+// NewRouter validates configs and starts running every pipeline. Errors
+// returned by maps and posts are reported to the notifier's error handler
+// (see WithErrorHandler) with the pipeline's source event. If a source event
+// can't be observed (see Subscription.Err) the pipelines already started are
+// stopped and the error is returned
+func (notifier *Notifier) NewRouter(configs []PipelineConfig, funcs PipelineFuncs) (*Router, error) {
+	built := make([][]pipelineStage, len(configs))
+	for i, config := range configs {
+		stages, err := notifier.buildPipeline(config, funcs)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidPipeline, config.Name, err)
+		}
+		built[i] = stages
+	}
+
+	router := &Router{notifier: notifier}
+	for i, config := range configs {
+		if err := router.run(config, built[i]); err != nil {
+			router.Close()
+			return nil, err
+		}
+	}
+	return router, nil
+}
+
+func (notifier *Notifier) buildPipeline(config PipelineConfig, funcs PipelineFuncs) ([]pipelineStage, error) {
+	if config.From == "" {
+		return nil, errors.New("no source event")
+	}
+	routed := false
+	var stages []pipelineStage
+	for _, stage := range config.Stages {
+		switch {
+		case stage.Filter != "":
+			filter, ok := funcs.Filters[stage.Filter]
+			if !ok {
+				return nil, fmt.Errorf("unknown filter %q", stage.Filter)
+			}
+			stages = append(stages, filterStage(filter))
+		case stage.Map != "":
+			fn, ok := funcs.Maps[stage.Map]
+			if !ok {
+				return nil, fmt.Errorf("unknown map %q", stage.Map)
+			}
+			stages = append(stages, notifier.mapStage(config.From, fn))
+		case stage.Batch != nil:
+			var interval time.Duration
+			if stage.Batch.Interval != "" {
+				var err error
+				if interval, err = time.ParseDuration(stage.Batch.Interval); err != nil {
+					return nil, err
+				}
+			}
+			if stage.Batch.Size <= 0 && interval <= 0 {
+				return nil, errors.New("batch without size or interval")
+			}
+			stages = append(stages, batchStage(notifier.clock, stage.Batch.Size, interval))
+		case stage.Route != "":
+			route, ok := funcs.Routes[stage.Route]
+			if !ok {
+				return nil, fmt.Errorf("unknown route %q", stage.Route)
+			}
+			stages = append(stages, routeStage(route))
+			routed = true
+		default:
+			return nil, errors.New("empty stage")
+		}
+	}
+	if config.To == "" && !routed {
+		return nil, errors.New("no destination event")
+	}
+	return stages, nil
+}
+
+// Observe the pipeline's source event and connect the stages with channels,
+// posting what comes out of the last one
+func (router *Router) run(config PipelineConfig, stages []pipelineStage) error {
+	component := "pipeline " + config.Name
+	input, subscription := router.notifier.StartOwned(config.From, 0, WithComponent(component))
+	if err := subscription.Err(); err != nil {
+		return err
+	}
+	router.subscriptions = append(router.subscriptions, subscription)
+	if config.To != "" {
+		router.flows = append(router.flows, router.notifier.addFlow(component, config.To))
+	}
+
+	in := make(chan pipelineItem)
+	router.wg.Add(1)
+	go func() {
+		defer router.wg.Done()
+		defer close(in)
+
+		for data := range input {
+			in <- pipelineItem{data: data, to: config.To}
+		}
+	}()
+
+	var out <-chan pipelineItem = in
+	for _, stage := range stages {
+		next := make(chan pipelineItem)
+		router.wg.Add(1)
+		go func(stage pipelineStage, in <-chan pipelineItem) {
+			defer router.wg.Done()
+			stage(in, next)
+		}(stage, out)
+		out = next
+	}
+
+	router.wg.Add(1)
+	go func() {
+		defer router.wg.Done()
+
+		for item := range out {
+			if item.to == "" {
+				continue
+			}
+			if err := router.notifier.Post(item.to, item.data); err != nil {
+				router.notifier.reportError(config.From, err)
+			}
+		}
+	}()
+	return nil
+}
+
+func filterStage(filter func(data interface{}) bool) pipelineStage {
+	return func(in <-chan pipelineItem, out chan<- pipelineItem) {
+		defer close(out)
+
+		for item := range in {
+			if filter(item.data) {
+				out <- item
+			}
+		}
+	}
+}
+
+func (notifier *Notifier) mapStage(event string, fn func(data interface{}) (interface{}, error)) pipelineStage {
+	return func(in <-chan pipelineItem, out chan<- pipelineItem) {
+		defer close(out)
+
+		for item := range in {
+			data, err := fn(item.data)
+			if err != nil {
+				notifier.reportError(event, err)
+				continue
+			}
+			item.data = data
+			out <- item
+		}
+	}
+}
+
+func routeStage(route func(data interface{}) string) pipelineStage {
+	return func(in <-chan pipelineItem, out chan<- pipelineItem) {
+		defer close(out)
+
+		for item := range in {
+			item.to = route(item.data)
+			out <- item
+		}
+	}
+}
+
+// Batches go to the destination of their first notification
+func batchStage(clock Clock, size int, interval time.Duration) pipelineStage {
+	return func(in <-chan pipelineItem, out chan<- pipelineItem) {
+		defer close(out)
+
+		var batch []interface{}
+		var to string
+		var timer Timer
+		var expired <-chan time.Time
+		flush := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, expired = nil, nil
+			}
+			if len(batch) > 0 {
+				out <- pipelineItem{data: batch, to: to}
+				batch = nil
+			}
+		}
+
+		for {
+			select {
+			case item, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				if len(batch) == 0 {
+					to = item.to
+					if interval > 0 {
+						timer = clock.NewTimer(interval)
+						expired = timer.C()
+					}
+				}
+				batch = append(batch, item.data)
+				if size > 0 && len(batch) >= size {
+					flush()
+				}
+			case <-expired:
+				flush()
+			}
+		}
+	}
+}
+
+// Close stops every pipeline once the notifications already in them have
+// been posted. Partial batches are flushed
+func (router *Router) Close() error {
+	router.closeOnce.Do(func() {
+		for _, subscription := range router.subscriptions {
+			subscription.Unsubscribe()
+		}
+		for _, unregister := range router.flows {
+			unregister()
+		}
+		router.wg.Wait()
+	})
+	return nil
+}
+
+// Pipe builds a pipeline in code rather than from a PipelineConfig:
+//
+//	router, err := notifier.Pipe("raw.metrics").Filter(valid).Map(normalize).To("clean.metrics")
+//	if err != nil {
+//		...
+//	}
+//	defer router.Close()
+//
+// Every method returns a new Pipe, so a common prefix can be shared by
+// several pipelines
+type Pipe struct {
+	notifier *Notifier
+	from     string
+	stages   []pipelineStage
+}
+
+// Pipe starts building a pipeline reading notifications posted to event
+func (notifier *Notifier) Pipe(event string) *Pipe {
+	return &Pipe{notifier: notifier, from: event}
+}
+
+func (pipe *Pipe) then(stage pipelineStage) *Pipe {
+	next := *pipe
+	next.stages = append(pipe.stages[:len(pipe.stages):len(pipe.stages)], stage)
+	return &next
+}
+
+// Filter drops notifications for which filter returns false
+func (pipe *Pipe) Filter(filter func(data interface{}) bool) *Pipe {
+	return pipe.then(filterStage(filter))
+}
+
+// Map replaces notifications with the result of fn. Errors drop the
+// notification and are reported to the error handler
+func (pipe *Pipe) Map(fn func(data interface{}) (interface{}, error)) *Pipe {
+	return pipe.then(pipe.notifier.mapStage(pipe.from, fn))
+}
+
+// Batch groups notifications into []interface{} values, emitted once a batch
+// holds size notifications or interval has passed since its first one. Either
+// may be zero
+func (pipe *Pipe) Batch(size int, interval time.Duration) *Pipe {
+	return pipe.then(batchStage(pipe.notifier.clock, size, interval))
+}
+
+// Route posts notifications to the event returned by route instead of the
+// destination given to To. Notifications routed to "" are dropped
+func (pipe *Pipe) Route(route func(data interface{}) string) *Pipe {
+	return pipe.then(routeStage(route))
+}
+
+// To starts running the pipeline, posting what comes out of its last stage to
+// event. event may be empty if the pipeline has a Route stage. It fails if
+// the source event can't be observed (see Subscription.Err)
+func (pipe *Pipe) To(event string) (*Router, error) {
+	router := &Router{notifier: pipe.notifier}
+	config := PipelineConfig{Name: pipe.from + " -> " + event, From: pipe.from, To: event}
+
+	if err := router.run(config, pipe.stages); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
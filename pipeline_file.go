@@ -0,0 +1,29 @@
+//go:build !notifyminimal
+
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// LoadPipelines decodes a JSON array of pipelines
+func LoadPipelines(r io.Reader) ([]PipelineConfig, error) {
+	var configs []PipelineConfig
+	if err := json.NewDecoder(r).Decode(&configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// LoadPipelinesFile decodes the pipelines in the JSON file at path
+func LoadPipelinesFile(path string) ([]PipelineConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadPipelines(file)
+}
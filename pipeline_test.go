@@ -0,0 +1,102 @@
+package notify_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+func TestRouterRuns(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	outputChan := make(chan interface{}, 1)
+	notifier.Start("alerts", outputChan)
+
+	router, err := notifier.NewRouter([]notify.PipelineConfig{{
+		Name:   "large-orders",
+		From:   "orders",
+		To:     "alerts",
+		Stages: []notify.StageConfig{{Filter: "large"}},
+	}}, notify.PipelineFuncs{
+		Filters: map[string]func(interface{}) bool{
+			"large": func(data interface{}) bool { return data.(int) > 100 },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer router.Close()
+
+	notifier.Post("orders", 5)
+	notifier.Post("orders", 500)
+	select {
+	case got := <-outputChan:
+		if got != 500 {
+			t.Fatalf("routed %v, want 500", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing routed")
+	}
+}
+
+func TestNewRouterUnobservableSource(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxEvents(1))
+	defer notifier.Close()
+
+	router, err := notifier.NewRouter([]notify.PipelineConfig{
+		{Name: "orders", From: "orders", To: "alerts"},
+		{Name: "payments", From: "payments", To: "alerts"},
+	}, notify.PipelineFuncs{})
+	if !errors.Is(err, notify.ErrEventLimit) {
+		t.Fatalf("NewRouter returned %v, want ErrEventLimit", err)
+	}
+	if router != nil {
+		t.Fatal("NewRouter returned a router along with its error")
+	}
+	for _, snapshot := range notifier.Snapshot() {
+		if len(snapshot.Subscribers) > 0 {
+			t.Fatalf("%s still observed after NewRouter failed", snapshot.Name)
+		}
+	}
+}
+
+func TestPipeTo(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	outputChan := make(chan interface{}, 1)
+	notifier.Start("clean.metrics", outputChan)
+
+	router, err := notifier.Pipe("raw.metrics").
+		Map(func(data interface{}) (interface{}, error) { return data.(int) * 2, nil }).
+		To("clean.metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer router.Close()
+
+	notifier.Post("raw.metrics", 21)
+	select {
+	case got := <-outputChan:
+		if got != 42 {
+			t.Fatalf("piped %v, want 42", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing piped")
+	}
+}
+
+func TestPipeToUnobservableSource(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxEvents(1))
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 1))
+
+	router, err := notifier.Pipe("raw.metrics").To("clean.metrics")
+	if !errors.Is(err, notify.ErrEventLimit) {
+		t.Fatalf("To returned %v, want ErrEventLimit", err)
+	}
+	if router != nil {
+		t.Fatal("To returned a router along with its error")
+	}
+}
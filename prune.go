@@ -0,0 +1,69 @@
+package notify
+
+import "time"
+
+// WithAutoPrune removes events that have had no observers and no posts for
+// ttl in the background, checking every ttl until the notifier is closed
+func WithAutoPrune(ttl time.Duration) Option {
+	return func(notifier *Notifier) {
+		notifier.eventTTL = ttl
+		notifier.autoPrune = ttl > 0
+	}
+}
+
+// WithEventTTL keeps events without observers from being removed by Prune
+// until they have had no posts for ttl. By default Prune removes every event
+// without observers
+func WithEventTTL(ttl time.Duration) Option {
+	return func(notifier *Notifier) {
+		notifier.eventTTL = ttl
+	}
+}
+
+// Prune removes events that have no observers and have been idle for the
+// notifier's event TTL, returning how many were removed. Applications using
+// per-request or per-connection event names should prune periodically so the
+// notifier doesn't keep every name it has ever seen. Removed events are
+// treated like events that were never started, and their history is
+// discarded, expiring resume tokens issued for them
+func (notifier *Notifier) Prune() int {
+	notifier.RLock()
+	defer notifier.RUnlock()
+
+	cutoff := notifier.clock.Now().Add(-notifier.eventTTL)
+	pruned := 0
+	for i := range notifier.shards {
+		shard := &notifier.shards[i]
+		shard.Lock()
+		for event, entry := range shard.events {
+			if len(entry.observers()) == 0 && !entry.activeAfter(cutoff) {
+				notifier.removeEntry(shard, event, entry)
+				pruned++
+			}
+		}
+		shard.Unlock()
+	}
+
+	return pruned
+}
+
+func (notifier *Notifier) runPrune() {
+	ticker := notifier.clock.NewTicker(notifier.eventTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-notifier.pruneStop:
+			return
+		case <-ticker.C():
+			notifier.Prune()
+		}
+	}
+}
+
+func (entry *eventEntry) activeAfter(t time.Time) bool {
+	entry.Lock()
+	defer entry.Unlock()
+
+	return entry.active.After(t)
+}
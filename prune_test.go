@@ -0,0 +1,137 @@
+package notify_test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+func hasEvent(notifier *notify.Notifier, event string) bool {
+	for _, name := range notifier.Events() {
+		if name == event {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPruneRemovesUnobservedEvents(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	notifier.Start("observed", make(chan interface{}, 1))
+	notifier.Start("abandoned", make(chan interface{}, 1)).Unsubscribe()
+
+	if pruned := notifier.Prune(); pruned != 1 {
+		t.Fatalf("pruned %d events, want 1", pruned)
+	}
+	if hasEvent(notifier, "abandoned") || !hasEvent(notifier, "observed") {
+		t.Fatalf("events after pruning: %v", notifier.Events())
+	}
+	if err := notifier.Post("abandoned", 1); err != notify.ErrEventNotFound {
+		t.Fatalf("post to a pruned event returned %v, want ErrEventNotFound", err)
+	}
+	if pruned := notifier.Prune(); pruned != 0 {
+		t.Fatalf("pruned %d events again", pruned)
+	}
+}
+
+func TestPruneKeepsEventsWithinTTL(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock), notify.WithEventTTL(time.Minute))
+	defer notifier.Close()
+
+	notifier.Start("session.1", make(chan interface{}, 1)).Unsubscribe()
+	clock.Advance(59 * time.Second)
+	if pruned := notifier.Prune(); pruned != 0 {
+		t.Fatalf("pruned %d events before their TTL", pruned)
+	}
+
+	clock.Advance(time.Second)
+	if pruned := notifier.Prune(); pruned != 1 || hasEvent(notifier, "session.1") {
+		t.Fatalf("pruned %d events once idle for the TTL, events %v", pruned, notifier.Events())
+	}
+}
+
+func TestPruneCountsPostsAsActivity(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock), notify.WithEventTTL(time.Minute))
+	defer notifier.Close()
+
+	notifier.Start("session.1", make(chan interface{}, 1)).Unsubscribe()
+	clock.Advance(50 * time.Second)
+	notifier.Post("session.1", 1)
+	clock.Advance(50 * time.Second)
+	if pruned := notifier.Prune(); pruned != 0 {
+		t.Fatalf("pruned %d events posted to within the TTL", pruned)
+	}
+}
+
+func TestAutoPrune(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock), notify.WithAutoPrune(time.Minute))
+	defer notifier.Close()
+
+	notifier.Start("session.1", make(chan interface{}, 1)).Unsubscribe()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(2 * time.Minute)
+
+	deadline := time.Now().Add(time.Second)
+	for hasEvent(notifier, "session.1") {
+		if time.Now().After(deadline) {
+			t.Fatal("event not pruned in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Events named after as many connections, each with an observer, as
+// applications with per-connection topics have
+const dynamicEvents = 10000
+
+func BenchmarkPostManyEvents(b *testing.B) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	events := make([]string, dynamicEvents)
+	for i := range events {
+		events[i] = "conn." + strconv.Itoa(i)
+	}
+	outputChan := make(chan interface{}, 1024)
+	notifier.StartMulti(events, outputChan, notify.WithChannelOwnership())
+	go func() {
+		for range outputChan {
+		}
+	}()
+
+	var next atomic.Uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			notifier.Post(events[next.Add(1)%dynamicEvents], 1)
+		}
+	})
+}
+
+func BenchmarkStartManyEvents(b *testing.B) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	var next atomic.Uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		outputChan := make(chan interface{}, 1)
+		for pb.Next() {
+			event := "conn." + strconv.FormatUint(next.Add(1), 10)
+			notifier.Start(event, outputChan).Unsubscribe()
+		}
+	})
+	b.StopTimer()
+	notifier.Prune()
+}
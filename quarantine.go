@@ -0,0 +1,37 @@
+package notify
+
+import "time"
+
+// QuarantineEvent is where bridges and gateways post external notifications
+// they reject, unless configured with another event
+const QuarantineEvent = "notify.quarantine"
+
+// Quarantined is the notification posted for a rejected external
+// notification, ie: one that was malformed, failed to decode or carried an
+// invalid signature
+type Quarantined struct {
+	Event    string // the event it was meant for, empty if unknown
+	Source   string // where it came from, ie: a bridge peer or remote address
+	Payload  []byte // as received
+	Err      error  // why it was rejected
+	Received time.Time
+}
+
+// PostQuarantined posts rejected to event, or QuarantineEvent if event is
+// empty, at SeverityWarning. The post is dropped if event isn't observed
+func (notifier *Notifier) PostQuarantined(event string, rejected Quarantined) error {
+	if notifier == nil {
+		return ErrNilNotifier
+	}
+	if event == "" {
+		event = QuarantineEvent
+	}
+	if rejected.Received.IsZero() {
+		rejected.Received = notifier.clock.Now()
+	}
+	err := notifier.PostSeverity(event, SeverityWarning, rejected)
+	if err == ErrEventNotFound || err == ErrNoSubscribers {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("Event rate limit exceeded")
+
+// RateLimitPolicy controls what Post does when an event exceeds its rate
+// limit
+type RateLimitPolicy int
+
+const (
+	// Wait for the limit to allow the post, up to the post's timeout or
+	// context if it has one
+	RateLimitBlock RateLimitPolicy = iota
+	// Silently drop the notification
+	RateLimitDrop
+	// Return ErrRateLimited
+	RateLimitError
+)
+
+// WithRateLimit allows posts to event at an average of perSecond per second
+// with bursts of up to burst posts, handling the rest according to policy.
+// Dropped and rejected notifications are neither journaled nor delivered
+func WithRateLimit(event string, perSecond float64, burst int, policy RateLimitPolicy) Option {
+	return func(notifier *Notifier) {
+		if burst < 1 {
+			burst = 1
+		}
+		notifier.rateLimits[event] = &rateLimit{
+			rate:   perSecond,
+			burst:  float64(burst),
+			policy: policy,
+			tokens: float64(burst),
+		}
+	}
+}
+
+// A token bucket
+type rateLimit struct {
+	rate   float64
+	burst  float64
+	policy RateLimitPolicy
+
+	sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// Take a token if one is available, otherwise return how long until one is
+func (limit *rateLimit) take(now time.Time) (time.Duration, bool) {
+	limit.Lock()
+	defer limit.Unlock()
+
+	if limit.last.IsZero() {
+		limit.last = now
+	}
+	if elapsed := now.Sub(limit.last); elapsed > 0 {
+		limit.tokens += elapsed.Seconds() * limit.rate
+		if limit.tokens > limit.burst {
+			limit.tokens = limit.burst
+		}
+		limit.last = now
+	}
+	if limit.tokens >= 1 {
+		limit.tokens--
+		return 0, true
+	}
+	if limit.rate <= 0 {
+		return time.Duration(1<<63 - 1), false
+	}
+	return time.Duration((1 - limit.tokens) / limit.rate * float64(time.Second)), false
+}
+
+// Apply event's rate limit to a post. Returns false if the post should be
+// dropped without an error. Replayed notifications aren't limited
+func (notifier *Notifier) allow(event string, pc postContext) (bool, error) {
+	limit, ok := notifier.rateLimit(event)
+	if !ok || pc.replay {
+		return true, nil
+	}
+	for {
+		wait, ok := limit.take(notifier.clock.Now())
+		if ok {
+			return true, nil
+		}
+		switch limit.policy {
+		case RateLimitDrop:
+			return false, nil
+		case RateLimitError:
+			return false, ErrRateLimited
+		}
+
+		if !pc.deadline.IsZero() && notifier.clock.Now().Add(wait).After(pc.deadline) {
+			return false, ErrPostTimedOut
+		}
+		timer := notifier.clock.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-pc.ctx.Done():
+			timer.Stop()
+			return false, pc.ctx.Err()
+		}
+	}
+}
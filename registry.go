@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var ErrAlreadyRegistered = errors.New("Notifier name already registered")
+
+var (
+	registry     = make(map[string]*Notifier)
+	registryLock sync.RWMutex
+)
+
+// Register makes notifier available to Lookup under name. Registering the same
+// notifier twice is a no op. Closed notifiers are deregistered automatically
+func Register(name string, notifier *Notifier) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if registered, ok := registry[name]; ok && registered != notifier {
+		return ErrAlreadyRegistered
+	}
+	registry[name] = notifier
+	return nil
+}
+
+// Lookup returns the notifier registered under name
+func Lookup(name string) (*Notifier, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	notifier, ok := registry[name]
+	return notifier, ok
+}
+
+// Deregister removes name if it is still registered to notifier, so an owner
+// shutting down late can't remove the notifier that replaced it. Returns false
+// if it wasn't
+func Deregister(name string, notifier *Notifier) bool {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if registry[name] != notifier {
+		return false
+	}
+	delete(registry, name)
+	return true
+}
+
+// Registered returns the names of all registered notifiers in sorted order
+func Registered() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Remove every name registered to notifier
+func deregisterAll(notifier *Notifier) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	for name, registered := range registry {
+		if registered == notifier {
+			delete(registry, name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import "time"
+
+// Replay posts every journaled notification for event posted at or after from
+// to the event's current observers, oldest first, without journaling them
+// again. Events without observers are handled according to the no subscribers
+// policy, except that replay doesn't fail because of them. Once it returns the
+// event counts as replayed for ReadyAfterReplay
+func (notifier *Notifier) Replay(event string, from time.Time) error {
+	if notifier.journal == nil {
+		return ErrNoJournal
+	}
+	pc := backgroundPost
+	pc.replay = true
+	err := notifier.journal.Replay(event, from, func(entry JournalEntry) error {
+		data, err := notifier.journalCodec.Unmarshal(entry.Payload)
+		if err != nil {
+			return err
+		}
+		err = notifier.post(event, data, pc, notifier.deliverBlocking)
+		if err == ErrEventNotFound || err == ErrNoSubscribers {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	notifier.markReplayed(event)
+	return nil
+}
+
+// A ReadyAfterReplay call waiting on events still replaying
+type replayWaiter struct {
+	events map[string]bool
+	ready  chan struct{}
+}
+
+// ReadyAfterReplay returns a channel that is closed once every listed event
+// has been replayed with Replay, or every journaled event if none are listed.
+// Applications can wait on it before serving traffic so observers have
+// reconstructed their state. Sticky events are restored by NewNotifier and
+// never need to be waited on
+func (notifier *Notifier) ReadyAfterReplay(events ...string) <-chan struct{} {
+	if len(events) == 0 {
+		for event := range notifier.journaled {
+			events = append(events, event)
+		}
+	}
+
+	notifier.replayLock.Lock()
+	defer notifier.replayLock.Unlock()
+
+	waiter := &replayWaiter{events: make(map[string]bool), ready: make(chan struct{})}
+	for _, event := range events {
+		restored := notifier.isSticky(event) && !notifier.journaled[event]
+		if !restored && !notifier.replayed[event] {
+			waiter.events[event] = true
+		}
+	}
+	if len(waiter.events) == 0 {
+		close(waiter.ready)
+		return waiter.ready
+	}
+	notifier.replayWaiters = append(notifier.replayWaiters, waiter)
+	return waiter.ready
+}
+
+func (notifier *Notifier) markReplayed(event string) {
+	notifier.replayLock.Lock()
+	defer notifier.replayLock.Unlock()
+
+	notifier.replayed[event] = true
+	waiters := notifier.replayWaiters[:0]
+	for _, waiter := range notifier.replayWaiters {
+		delete(waiter.events, event)
+		if len(waiter.events) == 0 {
+			close(waiter.ready)
+		} else {
+			waiters = append(waiters, waiter)
+		}
+	}
+	notifier.replayWaiters = waiters
+}
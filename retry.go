@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrDuplicateRetryDelay = errors.New("Retry delay listed twice")
+
+// DefaultRetryBuffer is the number of failed notifications each retry tier
+// holds before failing ones wait for room
+const DefaultRetryBuffer = 1024
+
+// FailedNotification is a notification that failed to be handled, as posted
+// to retry tiers and the dead letter event (see StartRetrying)
+type FailedNotification struct {
+	Event   string // the event the notification was posted to
+	Data    interface{}
+	Attempt int   // failed attempts so far
+	Err     error // why the last attempt failed
+	Failed  time.Time
+}
+
+// RetryEvent names the retry tier of event waiting delay, ie:
+// "orders.retry.5s" or "orders.retry.1m"
+func RetryEvent(event string, delay time.Duration) string {
+	name := delay.String()
+	if strings.HasSuffix(name, "m0s") {
+		name = strings.TrimSuffix(name, "0s")
+	}
+	if strings.HasSuffix(name, "h0m") {
+		name = strings.TrimSuffix(name, "0m")
+	}
+	return event + ".retry." + name
+}
+
+// DeadLetterEvent names the event notifications of event that failed every
+// retry are posted to, ie: "orders.dlq"
+func DeadLetterEvent(event string) string {
+	return event + ".dlq"
+}
+
+// StartRetrying observes event with handler and wires up the retry topic
+// pattern: a notification handler fails on is posted as a FailedNotification
+// to the first tier, RetryEvent(event, delays[0]), and handed to handler again
+// once the delay has passed since the failure, moving on to the next tier
+// every time it fails again. Once the last tier fails it is posted to
+// DeadLetterEvent(event).
+// Other observers can watch the tiers and the dead letter event like any
+// other. A handler wrapped in AckDeadline is also retried when it outlives
+// its deadline. options apply to the observer of event. Every failure is
+// reported to the error handler; retries still waiting are abandoned once the
+// returned Subscriptions are stopped. If the event or any tier can't be
+// observed (ie: over WithMaxEvents) nothing is left started and the error says
+// why. Each delay may only be listed once
+func (notifier *Notifier) StartRetrying(event string, handler Handler, delays []time.Duration, options ...SubscribeOption) (Subscriptions, error) {
+	seen := make(map[time.Duration]bool, len(delays))
+	for _, delay := range delays {
+		if seen[delay] {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateRetryDelay, delay)
+		}
+		seen[delay] = true
+	}
+
+	// The tiers are observed before event, so nothing has failed by the time
+	// a call is rolled back and a rolled back call never retries anything
+	subscriptions := make(Subscriptions, 1, len(delays)+1)
+	inputs := make([]<-chan interface{}, len(delays))
+	for tier, delay := range delays {
+		input, subscription := notifier.StartOwned(RetryEvent(event, delay), DefaultRetryBuffer)
+		if err := subscription.Err(); err != nil {
+			subscriptions[1:].Unsubscribe()
+			return nil, err
+		}
+		inputs[tier] = input
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	subscription := notifier.StartFunc(event, func(ctx context.Context, data interface{}) error {
+		err := handler(ctx, data)
+		if err != nil {
+			notifier.retry(event, delays, FailedNotification{Event: event, Data: data, Attempt: 1, Err: err, Failed: notifier.clock.Now()})
+		}
+		return err
+	}, options...)
+	if err := subscription.Err(); err != nil {
+		subscriptions[1:].Unsubscribe()
+		return nil, err
+	}
+	subscriptions[0] = subscription
+
+	for tier, input := range inputs {
+		go notifier.runRetryTier(handler, delays, tier, input, subscriptions[tier+1].sub.done)
+	}
+	return subscriptions, nil
+}
+
+// Post a failed notification to the tier after its attempt, or to the dead
+// letter event once it has been through every tier
+func (notifier *Notifier) retry(event string, delays []time.Duration, r FailedNotification) {
+	target := DeadLetterEvent(event)
+	if r.Attempt <= len(delays) {
+		target = RetryEvent(event, delays[r.Attempt-1])
+	}
+	if err := notifier.Post(target, r); err != nil {
+		notifier.reportError(target, err)
+	}
+}
+
+// Hand the notifications of a retry tier to handler once they are due
+func (notifier *Notifier) runRetryTier(handler Handler, delays []time.Duration, tier int, input <-chan interface{}, stopped <-chan struct{}) {
+	for data := range input {
+		r, ok := data.(FailedNotification)
+		if !ok {
+			continue
+		}
+		if wait := r.Failed.Add(delays[tier]).Sub(notifier.clock.Now()); wait > 0 {
+			timer := notifier.clock.NewTimer(wait)
+			select {
+			case <-timer.C():
+			case <-stopped:
+				timer.Stop()
+				return
+			}
+		}
+
+		ctx := context.WithValue(context.Background(), clockKey{}, notifier.clock)
+		if err := handler(ctx, r.Data); err != nil {
+			notifier.reportError(r.Event, err)
+			r.Attempt++
+			r.Err = err
+			r.Failed = notifier.clock.Now()
+			notifier.retry(r.Event, delays, r)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+// Advance clock by d once a retry is waiting on it
+func advanceRetry(t *testing.T, clock *notifytest.FakeClock, d time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clock.Timers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no retry waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(d)
+}
+
+func TestStartRetrying(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock))
+	defer notifier.Close()
+
+	var calls atomic.Int32
+	done := make(chan struct{})
+	_, err := notifier.StartRetrying("orders", func(ctx context.Context, data interface{}) error {
+		if calls.Add(1) < 3 {
+			return errDeliveryFailed
+		}
+		close(done)
+		return nil
+	}, []time.Duration{time.Second, time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier.Post("orders", 1)
+
+	advanceRetry(t, clock, time.Second)
+	advanceRetry(t, clock, time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("handler called %d times, want it retried by both tiers", calls.Load())
+	}
+}
+
+func TestStartRetryingDeadLetter(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock))
+	defer notifier.Close()
+
+	dead, _ := notifier.StartOwned(notify.DeadLetterEvent("orders"), 1)
+	_, err := notifier.StartRetrying("orders", func(ctx context.Context, data interface{}) error {
+		return errDeliveryFailed
+	}, []time.Duration{time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier.Post("orders", 1)
+
+	advanceRetry(t, clock, time.Second)
+	select {
+	case data := <-dead:
+		failed := data.(notify.FailedNotification)
+		if failed.Event != "orders" || failed.Data != 1 || failed.Attempt != 2 || failed.Err != errDeliveryFailed {
+			t.Fatalf("dead lettered %+v, want the second failure of 1", failed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing dead lettered once every tier failed")
+	}
+}
+
+func TestStartRetryingRollsBack(t *testing.T) {
+	notifier := notify.NewNotifier(notify.WithMaxEvents(1))
+	defer notifier.Close()
+
+	called := false
+	_, err := notifier.StartRetrying("orders", func(ctx context.Context, data interface{}) error {
+		called = true
+		return errDeliveryFailed
+	}, []time.Duration{time.Second})
+	if !errors.Is(err, notify.ErrEventLimit) {
+		t.Fatalf("StartRetrying returned %v, want ErrEventLimit", err)
+	}
+	for _, snapshot := range notifier.Snapshot() {
+		if len(snapshot.Subscribers) > 0 {
+			t.Fatalf("%s still observed after StartRetrying failed", snapshot.Name)
+		}
+	}
+	if err := notifier.Post("orders", 1); err == nil || called {
+		t.Fatal("rolled back handler still observing")
+	}
+}
+
+func TestStartRetryingDuplicateDelay(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	_, err := notifier.StartRetrying("orders", func(ctx context.Context, data interface{}) error {
+		return nil
+	}, []time.Duration{time.Second, time.Second})
+	if !errors.Is(err, notify.ErrDuplicateRetryDelay) {
+		t.Fatalf("StartRetrying returned %v, want ErrDuplicateRetryDelay", err)
+	}
+}
@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrAlreadySampling = errors.New("Event is already being sampled")
+
+// DefaultSampleSize is the number of samples kept when a SampleConfig doesn't
+// set one
+const DefaultSampleSize = 100
+
+// SampleConfig bounds the capture of an event's notifications by
+// StartSampling
+type SampleConfig struct {
+	// Notifications captured per second at most, zero captures every one
+	Rate float64
+	// Samples kept, the oldest being overwritten once full
+	Size int
+	// How long to capture for, zero captures until StopSampling
+	Duration time.Duration
+}
+
+// Sample is a notification captured by StartSampling
+type Sample struct {
+	Time time.Time
+	Data interface{}
+}
+
+// SamplingInfo describes an event being sampled
+type SamplingInfo struct {
+	Event    string
+	Config   SampleConfig
+	Started  time.Time
+	Until    time.Time // zero if capturing until StopSampling
+	Captured uint64
+	Skipped  uint64 // notifications over the rate
+}
+
+type sampler struct {
+	info  SamplingInfo
+	limit *rateLimit
+
+	sync.Mutex
+	ring []Sample
+	next int
+}
+
+// StartSampling captures the notifications posted to event into a ring buffer
+// within the bounds of config, for debugging a single event in production
+// without logging every payload. The samples are kept until StopSampling is
+// called, even once config.Duration has passed
+func (notifier *Notifier) StartSampling(event string, config SampleConfig) error {
+	if config.Size <= 0 {
+		config.Size = DefaultSampleSize
+	}
+	now := notifier.clock.Now()
+	s := &sampler{info: SamplingInfo{Event: notifier.canonical(event), Config: config, Started: now}}
+	if config.Duration > 0 {
+		s.info.Until = now.Add(config.Duration)
+	}
+	if config.Rate > 0 {
+		s.limit = &rateLimit{rate: config.Rate, burst: 1, tokens: 1}
+	}
+
+	notifier.samplerLock.Lock()
+	defer notifier.samplerLock.Unlock()
+
+	samplers := make(map[string]*sampler)
+	if current := notifier.samplers.Load(); current != nil {
+		for name, existing := range *current {
+			samplers[name] = existing
+		}
+	}
+	if _, ok := samplers[s.info.Event]; ok {
+		return ErrAlreadySampling
+	}
+	samplers[s.info.Event] = s
+	notifier.samplers.Store(&samplers)
+	return nil
+}
+
+// StopSampling stops sampling event and returns what was captured, oldest
+// first
+func (notifier *Notifier) StopSampling(event string) ([]Sample, bool) {
+	event = notifier.canonical(event)
+
+	notifier.samplerLock.Lock()
+	defer notifier.samplerLock.Unlock()
+
+	current := notifier.samplers.Load()
+	if current == nil {
+		return nil, false
+	}
+	s, ok := (*current)[event]
+	if !ok {
+		return nil, false
+	}
+	samplers := make(map[string]*sampler, len(*current)-1)
+	for name, existing := range *current {
+		if name != event {
+			samplers[name] = existing
+		}
+	}
+	notifier.samplers.Store(&samplers)
+	return s.samples(), true
+}
+
+// Samples returns the notifications captured so far for event, oldest first
+func (notifier *Notifier) Samples(event string) ([]Sample, bool) {
+	s, ok := notifier.sampler(notifier.canonical(event))
+	if !ok {
+		return nil, false
+	}
+	return s.samples(), true
+}
+
+// Sampling describes every event being sampled, sorted by name
+func (notifier *Notifier) Sampling() []SamplingInfo {
+	current := notifier.samplers.Load()
+	if current == nil {
+		return nil
+	}
+	infos := make([]SamplingInfo, 0, len(*current))
+	for _, s := range *current {
+		s.Lock()
+		infos = append(infos, s.info)
+		s.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Event < infos[j].Event })
+	return infos
+}
+
+func (notifier *Notifier) sampler(event string) (*sampler, bool) {
+	current := notifier.samplers.Load()
+	if current == nil {
+		return nil, false
+	}
+	s, ok := (*current)[event]
+	return s, ok
+}
+
+// Move the sampler of old to event unless event is being sampled already
+func (notifier *Notifier) mergeSampler(old string, event string) {
+	notifier.samplerLock.Lock()
+	defer notifier.samplerLock.Unlock()
+
+	current := notifier.samplers.Load()
+	if current == nil {
+		return
+	}
+	s, ok := (*current)[old]
+	if !ok {
+		return
+	}
+	samplers := make(map[string]*sampler, len(*current))
+	for name, existing := range *current {
+		if name != old {
+			samplers[name] = existing
+		}
+	}
+	if _, ok := samplers[event]; !ok {
+		s.Lock()
+		s.info.Event = event
+		s.Unlock()
+		samplers[event] = s
+	}
+	notifier.samplers.Store(&samplers)
+}
+
+// Capture a notification posted to event if it is being sampled
+func (notifier *Notifier) sample(event string, data interface{}) {
+	s, ok := notifier.sampler(event)
+	if !ok {
+		return
+	}
+	now := notifier.clock.Now()
+	if !s.info.Until.IsZero() && now.After(s.info.Until) {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.limit != nil {
+		if _, ok := s.limit.take(now); !ok {
+			s.info.Skipped++
+			return
+		}
+	}
+	if len(s.ring) < s.info.Config.Size {
+		s.ring = append(s.ring, Sample{Time: now, Data: data})
+	} else {
+		s.ring[s.next] = Sample{Time: now, Data: data}
+		s.next = (s.next + 1) % len(s.ring)
+	}
+	s.info.Captured++
+}
+
+func (s *sampler) samples() []Sample {
+	s.Lock()
+	defer s.Unlock()
+
+	samples := make([]Sample, 0, len(s.ring))
+	samples = append(samples, s.ring[s.next:]...)
+	return append(samples, s.ring[:s.next]...)
+}
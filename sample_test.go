@@ -0,0 +1,104 @@
+package notify_test
+
+import (
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+// Data of the samples captured for event
+func sampled(t *testing.T, notifier *notify.Notifier, event string) []interface{} {
+	t.Helper()
+	samples, ok := notifier.Samples(event)
+	if !ok {
+		t.Fatalf("%s not sampled", event)
+	}
+	var data []interface{}
+	for _, s := range samples {
+		data = append(data, s.Data)
+	}
+	return data
+}
+
+func TestSamplingKeepsNewest(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 5))
+
+	if err := notifier.StartSampling("orders", notify.SampleConfig{Size: 3}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		notifier.Post("orders", i)
+	}
+	if got := sampled(t, notifier, "orders"); len(got) != 3 || got[0] != 3 || got[2] != 5 {
+		t.Fatalf("sampled %v, want the newest 3, oldest first", got)
+	}
+
+	samples, ok := notifier.StopSampling("orders")
+	if !ok || len(samples) != 3 {
+		t.Fatalf("StopSampling returned %v, %v, want the 3 samples", samples, ok)
+	}
+	if _, ok := notifier.Samples("orders"); ok {
+		t.Fatal("still sampling after StopSampling")
+	}
+}
+
+func TestSamplingRate(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock))
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 3))
+
+	notifier.StartSampling("orders", notify.SampleConfig{Rate: 1})
+	notifier.Post("orders", 1)
+	notifier.Post("orders", 2)
+	clock.Advance(time.Second)
+	notifier.Post("orders", 3)
+
+	if got := sampled(t, notifier, "orders"); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("sampled %v, want 1 and 3", got)
+	}
+	if info := notifier.Sampling(); len(info) != 1 || info[0].Captured != 2 || info[0].Skipped != 1 {
+		t.Fatalf("sampling %+v, want 2 captured and 1 skipped", info)
+	}
+}
+
+func TestSamplingDuration(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock))
+	defer notifier.Close()
+	notifier.Start("orders", make(chan interface{}, 2))
+
+	notifier.StartSampling("orders", notify.SampleConfig{Duration: time.Minute})
+	notifier.Post("orders", 1)
+	clock.Advance(2 * time.Minute)
+	notifier.Post("orders", 2)
+
+	if got := sampled(t, notifier, "orders"); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("sampled %v, want only the post made in time", got)
+	}
+}
+
+func TestSamplingWithoutObservers(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	notifier.StartSampling("orders", notify.SampleConfig{})
+	notifier.Post("orders", 1)
+	if got := sampled(t, notifier, "orders"); len(got) != 1 {
+		t.Fatalf("sampled %v, want the post nobody observed", got)
+	}
+}
+
+func TestSamplingTwice(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	notifier.StartSampling("orders", notify.SampleConfig{})
+	if err := notifier.StartSampling("orders", notify.SampleConfig{}); err != notify.ErrAlreadySampling {
+		t.Fatalf("StartSampling returned %v, want ErrAlreadySampling", err)
+	}
+}
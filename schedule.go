@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAlreadyScheduled = errors.New("Event already scheduled")
+	ErrNotScheduled     = errors.New("Event not scheduled")
+)
+
+// MisfirePolicy controls what happens to occurrences of a schedule that were
+// missed, either because the process was down (with a ScheduleStore
+// configured) or because the scheduler woke up late (ie: a blocked observer, a
+// suspended machine or paused container, or the clock jumping forward)
+type MisfirePolicy int
+
+const (
+	// Drop missed occurrences and wait for the next one (default)
+	MisfireSkip MisfirePolicy = iota
+	// Post every missed occurrence, oldest first, before resuming the schedule
+	MisfireCatchUp
+	// Post the latest missed occurrence once, unless an occurrence that is
+	// on time follows it
+	MisfireLatest
+)
+
+// DefaultMisfireGrace is how late an occurrence may fire before it is treated
+// as a misfire
+const DefaultMisfireGrace = time.Second
+
+// DefaultScheduleCheck is the longest a schedule waits before reading the
+// clock again, so time spent suspended and clock jumps are noticed even
+// though timers don't follow them
+const DefaultScheduleCheck = time.Minute
+
+// ScheduleStore persists the last run of each scheduled event so misfires that
+// happened while the process was down are handled on restart
+type ScheduleStore interface {
+	// LastRun returns the zero time if event has never run
+	LastRun(event string) (time.Time, error)
+	SaveLastRun(event string, t time.Time) error
+}
+
+// WithScheduleStore persists schedule progress to store
+func WithScheduleStore(store ScheduleStore) Option {
+	return func(notifier *Notifier) {
+		notifier.scheduleStore = store
+	}
+}
+
+// ScheduleOption configures a single schedule
+type ScheduleOption func(*schedule)
+
+// InLocation evaluates the cron expression in loc instead of time.Local.
+// A TZ= prefix in the expression takes precedence
+func InLocation(loc *time.Location) ScheduleOption {
+	return func(s *schedule) {
+		s.location = loc
+	}
+}
+
+// WithMisfire sets how missed occurrences are handled
+func WithMisfire(policy MisfirePolicy) ScheduleOption {
+	return func(s *schedule) {
+		s.misfire = policy
+	}
+}
+
+// WithMisfireGrace sets how late an occurrence may fire before it is treated
+// as a misfire
+func WithMisfireGrace(grace time.Duration) ScheduleOption {
+	return func(s *schedule) {
+		s.grace = grace
+	}
+}
+
+// WithScheduleCheck sets the longest the schedule waits before reading the
+// clock again (see DefaultScheduleCheck)
+func WithScheduleCheck(interval time.Duration) ScheduleOption {
+	return func(s *schedule) {
+		s.check = interval
+	}
+}
+
+type schedule struct {
+	cron     *cronSchedule
+	location *time.Location
+	misfire  MisfirePolicy
+	grace    time.Duration
+	check    time.Duration
+	next     time.Time
+	stop     chan struct{}
+}
+
+// Schedule posts the time of every occurrence of the cron expression spec to
+// event. See parseCron for the supported syntax. Occurrences follow the
+// notifier's clock (see WithClock)
+func (notifier *Notifier) Schedule(event string, spec string, options ...ScheduleOption) error {
+	s := &schedule{
+		location: time.Local,
+		grace:    DefaultMisfireGrace,
+		check:    DefaultScheduleCheck,
+		stop:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	cron, err := parseCron(spec, s.location)
+	if err != nil {
+		return err
+	}
+	s.cron = cron
+
+	last := notifier.clock.Now()
+	if notifier.scheduleStore != nil {
+		stored, err := notifier.scheduleStore.LastRun(event)
+		if err != nil {
+			return err
+		}
+		if !stored.IsZero() {
+			last = stored
+		}
+	}
+
+	notifier.scheduleLock.Lock()
+	defer notifier.scheduleLock.Unlock()
+
+	if _, ok := notifier.schedules[event]; ok {
+		return ErrAlreadyScheduled
+	}
+	s.next = cron.next(last)
+	notifier.schedules[event] = s
+	go notifier.runSchedule(event, s)
+
+	return nil
+}
+
+// Unschedule stops posting scheduled occurrences to event
+func (notifier *Notifier) Unschedule(event string) error {
+	notifier.scheduleLock.Lock()
+	defer notifier.scheduleLock.Unlock()
+
+	s, ok := notifier.schedules[event]
+	if !ok {
+		return ErrNotScheduled
+	}
+	close(s.stop)
+	delete(notifier.schedules, event)
+
+	return nil
+}
+
+// NextRun returns the next time event is scheduled to be posted
+func (notifier *Notifier) NextRun(event string) (time.Time, bool) {
+	notifier.scheduleLock.Lock()
+	defer notifier.scheduleLock.Unlock()
+
+	s, ok := notifier.schedules[event]
+	if !ok || s.next.IsZero() {
+		return time.Time{}, false
+	}
+	return s.next, true
+}
+
+func (notifier *Notifier) runSchedule(event string, s *schedule) {
+	notifier.scheduleLock.Lock()
+	next := s.next
+	notifier.scheduleLock.Unlock()
+
+	for !next.IsZero() {
+		wait := next.Sub(notifier.clock.Now())
+		if s.check > 0 && wait > s.check {
+			wait = s.check
+		}
+		timer := notifier.clock.NewTimer(wait)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		now := notifier.clock.Now()
+		if next.After(now) {
+			continue
+		}
+		var latest time.Time
+		for ; !next.IsZero() && !next.After(now); next = s.cron.next(next) {
+			switch {
+			case now.Sub(next) <= s.grace || s.misfire == MisfireCatchUp:
+				latest = time.Time{}
+				notifier.Post(event, next)
+			case s.misfire == MisfireLatest:
+				latest = next
+			}
+			if notifier.scheduleStore != nil {
+				notifier.scheduleStore.SaveLastRun(event, next)
+			}
+		}
+		if !latest.IsZero() {
+			notifier.Post(event, latest)
+		}
+
+		notifier.scheduleLock.Lock()
+		s.next = next
+		notifier.scheduleLock.Unlock()
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !notifyminimal
+
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileScheduleStore is a ScheduleStore backed by a JSON file
+type FileScheduleStore struct {
+	path     string
+	lastRuns map[string]time.Time
+	sync.Mutex
+}
+
+// NewFileScheduleStore loads (or creates on first save) the store at path
+func NewFileScheduleStore(path string) (*FileScheduleStore, error) {
+	store := &FileScheduleStore{
+		path:     path,
+		lastRuns: make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.lastRuns); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (store *FileScheduleStore) LastRun(event string) (time.Time, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	return store.lastRuns[event], nil
+}
+
+func (store *FileScheduleStore) SaveLastRun(event string, t time.Time) error {
+	store.Lock()
+	defer store.Unlock()
+
+	store.lastRuns[event] = t
+	data, err := json.Marshal(store.lastRuns)
+	if err != nil {
+		return err
+	}
+	tmp := store.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, store.path)
+}
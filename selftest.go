@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var ErrSelfTestFailed = errors.New("Self test failed")
+
+// SelfTestEvent prefixes the events probes are posted to by SelfTest
+const SelfTestEvent = "notify.selftest."
+
+// DefaultSelfTestTimeout bounds SelfTest when its context has no deadline
+const DefaultSelfTestTimeout = 5 * time.Second
+
+var selfTestSeq atomic.Uint64
+
+// RegisterSelfTest adds probe to the checks run by SelfTest under name. Tees
+// and bridge links register their own. The returned function removes it
+func (notifier *Notifier) RegisterSelfTest(name string, probe func(ctx context.Context) error) func() {
+	notifier.selfTestLock.Lock()
+	defer notifier.selfTestLock.Unlock()
+
+	id := selfTestSeq.Add(1)
+	notifier.selfTests[id] = selfTest{name: name, probe: probe}
+	return func() {
+		notifier.selfTestLock.Lock()
+		defer notifier.selfTestLock.Unlock()
+
+		delete(notifier.selfTests, id)
+	}
+}
+
+type selfTest struct {
+	name  string
+	probe func(ctx context.Context) error
+}
+
+// SelfTest posts probe notifications to observers started on throwaway
+// events, both channels and Handlers, checks that any journal and sticky
+// store can be read, and runs every registered probe, ie: to gate deployments
+// on a healthy notifier. Every failure is reported, wrapped in
+// ErrSelfTestFailed
+func (notifier *Notifier) SelfTest(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultSelfTestTimeout)
+		defer cancel()
+	}
+
+	tests := []selfTest{
+		{name: "channel", probe: notifier.probeChannel},
+		{name: "handler", probe: notifier.probeHandler},
+	}
+	if notifier.journal != nil {
+		tests = append(tests, selfTest{name: "journal", probe: func(ctx context.Context) error {
+			return notifier.journal.Replay(SelfTestEvent+"journal", notifier.clock.Now(), func(JournalEntry) error {
+				return nil
+			})
+		}})
+	}
+	if notifier.stickyStore != nil {
+		tests = append(tests, selfTest{name: "sticky store", probe: func(ctx context.Context) error {
+			_, err := notifier.stickyStore.Load(SelfTestEvent + "sticky")
+			return err
+		}})
+	}
+
+	notifier.selfTestLock.Lock()
+	ids := make([]uint64, 0, len(notifier.selfTests))
+	for id := range notifier.selfTests {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		tests = append(tests, notifier.selfTests[id])
+	}
+	notifier.selfTestLock.Unlock()
+
+	var errs []error
+	for _, test := range tests {
+		if err := test.probe(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %v", ErrSelfTestFailed, test.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// A probe event no observer outside SelfTest uses
+func probeEvent(kind string) string {
+	return SelfTestEvent + kind + "." + strconv.FormatUint(selfTestSeq.Add(1), 10)
+}
+
+// Post a probe to an owned channel observer and wait for it
+func (notifier *Notifier) probeChannel(ctx context.Context) error {
+	event := probeEvent("channel")
+	outputChan, subscription := notifier.StartOwned(event, 1)
+	defer notifier.removeProbe(event, subscription)
+
+	probe := notifier.clock.Now().UnixNano()
+	if err := notifier.PostContext(ctx, event, probe); err != nil {
+		return err
+	}
+	select {
+	case data := <-outputChan:
+		if data != probe {
+			return fmt.Errorf("received %v instead of the probe", data)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Post a probe to a Handler and wait for it to be called
+func (notifier *Notifier) probeHandler(ctx context.Context) error {
+	event := probeEvent("handler")
+	received := make(chan interface{}, 1)
+	subscription := notifier.StartFunc(event, func(ctx context.Context, data interface{}) error {
+		received <- data
+		return nil
+	})
+	defer notifier.removeProbe(event, subscription)
+
+	probe := notifier.clock.Now().UnixNano()
+	if err := notifier.PostContext(ctx, event, probe); err != nil {
+		return err
+	}
+	select {
+	case data := <-received:
+		if data != probe {
+			return fmt.Errorf("received %v instead of the probe", data)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop a probe's observer and forget its event
+func (notifier *Notifier) removeProbe(event string, subscription *Subscription) {
+	subscription.Unsubscribe()
+
+	shard, event := notifier.lockEvent(event)
+	defer notifier.unlockEvent(shard)
+
+	if entry, ok := shard.events[event]; ok && len(entry.observers()) == 0 {
+		notifier.removeEntry(shard, event, entry)
+	}
+}
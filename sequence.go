@@ -0,0 +1,24 @@
+package notify
+
+// WithGlobalSequence numbers every notification across all events in the
+// order they were posted, in addition to the per event sequence. Both are
+// found in the notification's Envelope (see WithEnvelopes)
+func WithGlobalSequence() Option {
+	return func(notifier *Notifier) {
+		notifier.globalSequence = true
+	}
+}
+
+// WithOrderedDelivery delivers the posts to each event one at a time, in
+// sequence order, so every observer receives an event's notifications with
+// increasing sequence numbers even when they are posted concurrently.
+// Without it concurrent posts to the same event may reach observers in a
+// different order than their sequence numbers. Observers can detect gaps
+// left by the notifications they skip (see WithMinSeverity and Pause) from
+// the sequence numbers. Callback observers started WithConcurrency still
+// run out of order
+func WithOrderedDelivery() Option {
+	return func(notifier *Notifier) {
+		notifier.orderedDelivery = true
+	}
+}
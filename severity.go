@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Severity ranks how serious a notification is
+type Severity int
+
+const (
+	SeverityDebug Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return fmt.Sprintf("Severity(%d)", int(severity))
+}
+
+// WithMinSeverity only delivers notifications posted at severity or above to
+// the observer. Notifications posted without a severity are SeverityInfo
+func WithMinSeverity(severity Severity) SubscribeOption {
+	return func(sub *subscriber) {
+		sub.minSeverity = severity
+	}
+}
+
+// Whether a notification posted at severity is delivered to the observer
+func (sub *subscriber) accepts(severity Severity) bool {
+	return severity >= sub.minSeverity && !sub.paused.Load()
+}
+
+// Post a notification to the specified event at the provided severity, which
+// handlers find in the notification's Envelope
+func (notifier *Notifier) PostSeverity(event string, severity Severity, data interface{}) error {
+	pc := postContext{ctx: context.Background(), severity: severity}
+	return notifier.post(event, data, pc, notifier.deliverBlocking)
+}
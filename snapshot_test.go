@@ -0,0 +1,232 @@
+package notify_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+func TestPostDeliversToSnapshot(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	stalled := make(chan interface{})
+	notifier.Start("orders", stalled)
+	posted := make(chan error)
+	go func() {
+		posted <- notifier.Post("orders", 1)
+	}()
+	// Wait for the post to be blocked on the stalled observer
+	time.Sleep(10 * time.Millisecond)
+
+	late := make(chan interface{}, 1)
+	notifier.Start("orders", late)
+	if got := <-stalled; got != 1 {
+		t.Fatalf("stalled observer got %v, want 1", got)
+	}
+	if err := <-posted; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-late:
+		t.Fatalf("observer started after the post got %v", got)
+	default:
+	}
+
+	go notifier.Post("orders", 2)
+	<-stalled
+	if got := <-late; got != 2 {
+		t.Fatalf("late observer got %v, want 2", got)
+	}
+}
+
+func TestChurnDuringStalledPost(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	stalled := make(chan interface{})
+	notifier.Start("orders", stalled)
+	go notifier.Post("orders", 1)
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			subscription := notifier.Start("orders", make(chan interface{}, 1))
+			if err := subscription.Unsubscribe(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start and Unsubscribe blocked behind a stalled post")
+	}
+	<-stalled
+}
+
+func TestUnsubscribeStalledObserver(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	stalled := make(chan interface{})
+	subscription := notifier.Start("orders", stalled)
+	posted := make(chan error)
+	go func() {
+		posted <- notifier.Post("orders", 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := subscription.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-posted:
+	case <-time.After(time.Second):
+		t.Fatal("post still blocked on an observer that was stopped")
+	}
+}
+
+// rwmutexBus fans out under a read lock held for the whole delivery, as
+// notifiers did before posts read subscriber snapshots. It is the baseline
+// BenchmarkPostChurn compares against: it does none of a notifier's other
+// work, so what compares is how much each slows down once observers churn
+type rwmutexBus struct {
+	sync.RWMutex
+	events map[string][]chan interface{}
+}
+
+func (bus *rwmutexBus) start(event string, outputChan chan interface{}) {
+	bus.Lock()
+	defer bus.Unlock()
+
+	bus.events[event] = append(bus.events[event], outputChan)
+}
+
+func (bus *rwmutexBus) stop(event string, outputChan chan interface{}) {
+	bus.Lock()
+	defer bus.Unlock()
+
+	chans := bus.events[event]
+	for i, c := range chans {
+		if c == outputChan {
+			bus.events[event] = append(chans[:i:i], chans[i+1:]...)
+			return
+		}
+	}
+}
+
+func (bus *rwmutexBus) post(event string, data interface{}) {
+	bus.RLock()
+	defer bus.RUnlock()
+
+	for _, outputChan := range bus.events[event] {
+		outputChan <- data
+	}
+}
+
+// Post in parallel to observers that keep up, with a goroutine starting and
+// stopping an observer of the same event for as long as the benchmark runs
+// if churn is set
+func benchmarkChurn(b *testing.B, churn bool, start func(outputChan chan interface{}) func(), post func(data interface{})) {
+	const observers = 8
+	drain := func() chan interface{} {
+		outputChan := make(chan interface{}, 128)
+		go func() {
+			for range outputChan {
+			}
+		}()
+		return outputChan
+	}
+	for i := 0; i < observers; i++ {
+		outputChan := drain()
+		stop := start(outputChan)
+		defer func() {
+			stop()
+			close(outputChan)
+		}()
+	}
+
+	stop := make(chan struct{})
+	churned := make(chan struct{})
+	outputChan := drain()
+	defer close(outputChan)
+	go func() {
+		defer close(churned)
+		for churn {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			start(outputChan)()
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			post(1)
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-churned
+}
+
+func BenchmarkPostChurn(b *testing.B) {
+	for _, churn := range []bool{false, true} {
+		name := "idle"
+		if churn {
+			name = "churn"
+		}
+		b.Run("snapshot/"+name, func(b *testing.B) {
+			notifier := notify.NewNotifier()
+			defer notifier.Close()
+
+			benchmarkChurn(b, churn, func(outputChan chan interface{}) func() {
+				subscription := notifier.Start("orders", outputChan)
+				return func() { subscription.Unsubscribe() }
+			}, func(data interface{}) {
+				notifier.Post("orders", data)
+			})
+		})
+		b.Run("rwmutex/"+name, func(b *testing.B) {
+			bus := &rwmutexBus{events: make(map[string][]chan interface{})}
+			benchmarkChurn(b, churn, func(outputChan chan interface{}) func() {
+				bus.start("orders", outputChan)
+				return func() { bus.stop("orders", outputChan) }
+			}, func(data interface{}) {
+				bus.post("orders", data)
+			})
+		})
+	}
+}
+
+func BenchmarkPostObservers(b *testing.B) {
+	for _, observers := range []int{1, 16, 256} {
+		b.Run(strconv.Itoa(observers), func(b *testing.B) {
+			notifier := notify.NewNotifier()
+			defer notifier.Close()
+
+			for i := 0; i < observers; i++ {
+				outputChan := make(chan interface{}, 128)
+				notifier.Start("orders", outputChan, notify.WithChannelOwnership())
+				go func() {
+					for range outputChan {
+					}
+				}()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				notifier.Post("orders", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// FileOp is the kind of change a FileEvent reports
+type FileOp int
+
+const (
+	FileCreated FileOp = iota
+	FileModified
+	FileRemoved
+)
+
+func (op FileOp) String() string {
+	switch op {
+	case FileCreated:
+		return "created"
+	case FileModified:
+		return "modified"
+	case FileRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// FileEvent is the notification posted by Files for a change
+type FileEvent struct {
+	Path string
+	Op   FileOp
+	Time time.Time // when the change was noticed
+}
+
+// Files polls paths every interval, as told by the notifier's clock (see
+// notify.WithClock), and posts a FileEvent to event for every file created,
+// modified (its size or modification time changed) or removed since the
+// previous poll. Directories are watched by their immediate entries. Changes
+// made and undone between two polls go unnoticed
+func Files(notifier *notify.Notifier, event string, interval time.Duration, paths ...string) *Source {
+	seen := scanFiles(paths)
+
+	return start(func(stop <-chan struct{}) {
+		ticker := notifier.Clock().NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C():
+				current := scanFiles(paths)
+				for _, change := range diffFiles(seen, current) {
+					change.Time = now
+					notifier.Post(event, change)
+				}
+				seen = current
+			}
+		}
+	})
+}
+
+// What a poll learns about a file
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// The regular files named by paths or directly inside the directories among
+// them
+func scanFiles(paths []string) map[string]fileState {
+	files := make(map[string]fileState)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if info, err := entry.Info(); err == nil {
+				files[filepath.Join(path, entry.Name())] = fileState{size: info.Size(), modTime: info.ModTime()}
+			}
+		}
+	}
+	return files
+}
+
+// The changes between two scans, sorted by path
+func diffFiles(before map[string]fileState, after map[string]fileState) []FileEvent {
+	var changes []FileEvent
+	for path, state := range after {
+		previous, ok := before[path]
+		switch {
+		case !ok:
+			changes = append(changes, FileEvent{Path: path, Op: FileCreated})
+		case previous != state:
+			changes = append(changes, FileEvent{Path: path, Op: FileModified})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, FileEvent{Path: path, Op: FileRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
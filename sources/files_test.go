@@ -0,0 +1,67 @@
+package sources_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jesus-ramos/go-notify/sources"
+)
+
+func TestFiles(t *testing.T) {
+	notifier, clock, outputChan := observed(t, "files")
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "config")
+	if err := os.WriteFile(watched, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	source := sources.Files(notifier, "files", time.Second, dir)
+	defer source.Stop()
+
+	created := filepath.Join(dir, "extra")
+	for _, step := range []struct {
+		change func() error
+		want   sources.FileEvent
+	}{
+		{func() error { return os.WriteFile(created, nil, 0o600) }, sources.FileEvent{Path: created, Op: sources.FileCreated}},
+		{func() error { return os.WriteFile(watched, []byte("v2 longer"), 0o600) }, sources.FileEvent{Path: watched, Op: sources.FileModified}},
+		{func() error { return os.Remove(created) }, sources.FileEvent{Path: created, Op: sources.FileRemoved}},
+	} {
+		if err := step.change(); err != nil {
+			t.Fatal(err)
+		}
+		tick(t, clock, time.Second)
+		got := receive(t, outputChan).(sources.FileEvent)
+		if got.Path != step.want.Path || got.Op != step.want.Op || !got.Time.Equal(clock.Now()) {
+			t.Fatalf("posted %+v, want %s %s at %v", got, step.want.Path, step.want.Op, clock.Now())
+		}
+	}
+
+	tick(t, clock, time.Second)
+	select {
+	case data := <-outputChan:
+		t.Fatalf("posted %+v without a change", data)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestFilesIgnoresSubdirectories(t *testing.T) {
+	notifier, clock, outputChan := observed(t, "files")
+	dir := t.TempDir()
+	source := sources.Files(notifier, "files", time.Second, dir)
+	defer source.Stop()
+
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nested", "deep"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tick(t, clock, time.Second)
+	select {
+	case data := <-outputChan:
+		t.Fatalf("posted %+v for a nested change", data)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,57 @@
+package sources
+
+import (
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// SignalPrefix prefixes the events signals are posted to
+const SignalPrefix = "signal."
+
+// Short names of signals, as used in their events
+var signalNames = map[os.Signal]string{
+	syscall.SIGHUP:  "hup",
+	syscall.SIGINT:  "int",
+	syscall.SIGQUIT: "quit",
+	syscall.SIGTERM: "term",
+	syscall.SIGPIPE: "pipe",
+	syscall.SIGALRM: "alrm",
+}
+
+// SignalEvent returns the event sig is posted to, ie: "signal.hup" for
+// SIGHUP. Signals without a short name use their number
+func SignalEvent(sig os.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return SignalPrefix + name
+	}
+	if number, ok := sig.(syscall.Signal); ok {
+		return SignalPrefix + strconv.Itoa(int(number))
+	}
+	return SignalPrefix + strings.ToLower(sig.String())
+}
+
+// Signals posts every incoming signal among sigs to its SignalEvent, with the
+// os.Signal as data. As with signal.Notify, the signals no longer get their
+// default behavior, ie: terminating the process, until the source is stopped
+func Signals(notifier *notify.Notifier, sigs ...os.Signal) *Source {
+	incoming := make(chan os.Signal, 1)
+	signal.Notify(incoming, sigs...)
+
+	return start(func(stop <-chan struct{}) {
+		defer signal.Stop(incoming)
+
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-incoming:
+				notifier.Post(SignalEvent(sig), sig)
+			}
+		}
+	})
+}
@@ -0,0 +1,49 @@
+//go:build unix
+
+package sources_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/sources"
+)
+
+type namedSignal string
+
+func (sig namedSignal) String() string { return string(sig) }
+func (namedSignal) Signal()            {}
+
+func TestSignalEvent(t *testing.T) {
+	for _, test := range []struct {
+		sig  os.Signal
+		want string
+	}{
+		{syscall.SIGHUP, "signal.hup"},
+		{syscall.SIGUSR1, "signal.usr1"},
+		{syscall.Signal(63), "signal.63"},
+		{namedSignal("Custom"), "signal.custom"},
+	} {
+		if got := sources.SignalEvent(test.sig); got != test.want {
+			t.Errorf("SignalEvent(%v) = %s, want %s", test.sig, got, test.want)
+		}
+	}
+}
+
+func TestSignals(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+	outputChan := make(chan interface{}, 1)
+	notifier.Start("signal.usr1", outputChan)
+
+	source := sources.Signals(notifier, syscall.SIGUSR1)
+	defer source.Stop()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, outputChan); got != syscall.SIGUSR1 {
+		t.Fatalf("posted %v, want SIGUSR1", got)
+	}
+}
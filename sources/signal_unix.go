@@ -0,0 +1,12 @@
+//go:build unix
+
+package sources
+
+import "syscall"
+
+func init() {
+	signalNames[syscall.SIGUSR1] = "usr1"
+	signalNames[syscall.SIGUSR2] = "usr2"
+	signalNames[syscall.SIGWINCH] = "winch"
+	signalNames[syscall.SIGCHLD] = "chld"
+}
@@ -0,0 +1,65 @@
+// Package sources provides ready-made producers posting into a Notifier: OS
+// signals, tickers and file system changes.
+//
+// Example:
+//
+//	hup := sources.Signals(notifier, syscall.SIGHUP)
+//	defer hup.Stop()
+//
+//	reload := make(chan interface{}, 1)
+//	notifier.Start("signal.hup", reload)
+//
+// Every source posts with Post, so notifications posted while an event has no
+// observers are handled by the notifier's no subscribers policy.
+package sources
+
+import (
+	"sync"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+// Source is a running producer
+type Source struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Start running produce on its own goroutine. produce must return once stop
+// is closed
+func start(produce func(stop <-chan struct{})) *Source {
+	source := &Source{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(source.done)
+		produce(source.stop)
+	}()
+	return source
+}
+
+// Stop stops the source and waits for it to finish posting
+func (source *Source) Stop() {
+	source.stopOnce.Do(func() {
+		close(source.stop)
+	})
+	<-source.done
+}
+
+// Ticker posts the current time.Time to event every interval, as told by the
+// notifier's clock (see notify.WithClock)
+func Ticker(notifier *notify.Notifier, event string, interval time.Duration) *Source {
+	return start(func(stop <-chan struct{}) {
+		ticker := notifier.Clock().NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C():
+				notifier.Post(event, now)
+			}
+		}
+	})
+}
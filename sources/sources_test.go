@@ -0,0 +1,76 @@
+package sources_test
+
+import (
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+	"github.com/jesus-ramos/go-notify/sources"
+)
+
+// A notifier on a fake clock with an observer of event
+func observed(t *testing.T, event string) (*notify.Notifier, *notifytest.FakeClock, chan interface{}) {
+	t.Helper()
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := notify.NewNotifier(notify.WithClock(clock))
+	t.Cleanup(func() { notifier.Close() })
+	outputChan := make(chan interface{}, 16)
+	notifier.Start(event, outputChan)
+	return notifier, clock, outputChan
+}
+
+// Advance clock by d once the source has started its ticker
+func tick(t *testing.T, clock *notifytest.FakeClock, d time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clock.Timers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("source never started its ticker")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(d)
+}
+
+func receive(t *testing.T, outputChan <-chan interface{}) interface{} {
+	t.Helper()
+	select {
+	case data := <-outputChan:
+		return data
+	case <-time.After(time.Second):
+		t.Fatal("nothing posted")
+	}
+	return nil
+}
+
+func TestTicker(t *testing.T) {
+	notifier, clock, outputChan := observed(t, "tick")
+	source := sources.Ticker(notifier, "tick", time.Second)
+	defer source.Stop()
+
+	for want := int64(1); want <= 2; want++ {
+		tick(t, clock, time.Second)
+		if got := receive(t, outputChan); !got.(time.Time).Equal(time.Unix(want, 0)) {
+			t.Fatalf("ticked %v, want %v", got, time.Unix(want, 0))
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	notifier, clock, outputChan := observed(t, "tick")
+	source := sources.Ticker(notifier, "tick", time.Second)
+	tick(t, clock, 0)
+
+	source.Stop()
+	source.Stop()
+	if clock.Timers() != 0 {
+		t.Fatal("stopped source left its ticker running")
+	}
+	clock.Advance(time.Minute)
+	select {
+	case data := <-outputChan:
+		t.Fatalf("stopped source posted %v", data)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
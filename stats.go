@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+// StatsVersion is the version of the schema Stats are exported with by
+// MarshalJSON and MarshalText. Counters may be added to a version; renaming or
+// removing one, or changing what it counts, bumps it
+const StatsVersion = 1
+
+// Stats are counters a notifier keeps from the moment it is created
+type Stats struct {
+	// Posts to events without observers, handled by the no subscribers
+	// policy (see WithNoSubscribers)
+	Unobserved uint64
+	// Unobserved posts that returned before being copied, recorded or
+	// generated (see Post and PostGenerateData)
+	ShortCircuited uint64
+	// Notifications posted to the recorder and those dropped because its
+	// queue was full (see WithMirror)
+	Mirrored      uint64
+	MirrorDropped uint64
+	// Copies of a notification that failed to decode for an observer, which
+	// missed it (see WithCopyOnPost)
+	CopyFailed uint64
+	// Bytes currently retained against the memory budget, zero without one
+	// (see WithMaxMemory). Unlike the others it goes down as well as up
+	Retained uint64
+}
+
+type counters struct {
+	unobserved     atomic.Uint64
+	shortCircuited atomic.Uint64
+	mirrored       atomic.Uint64
+	mirrorDropped  atomic.Uint64
+	copyFailed     atomic.Uint64
+}
+
+// Stats returns the notifier's counters
+func (notifier *Notifier) Stats() Stats {
+	return Stats{
+		Unobserved:     notifier.stats.unobserved.Load(),
+		ShortCircuited: notifier.stats.shortCircuited.Load(),
+		Mirrored:       notifier.stats.mirrored.Load(),
+		MirrorDropped:  notifier.stats.mirrorDropped.Load(),
+		CopyFailed:     notifier.stats.copyFailed.Load(),
+		Retained:       notifier.memory.retained(),
+	}
+}
+
+// A counter as exported
+type statsField struct {
+	name  string
+	value uint64
+}
+
+// The exported counters, in schema order
+func (stats Stats) fields() []statsField {
+	return []statsField{
+		{"version", StatsVersion},
+		{"unobserved", stats.Unobserved},
+		{"short_circuited", stats.ShortCircuited},
+		{"mirrored", stats.Mirrored},
+		{"mirror_dropped", stats.MirrorDropped},
+		{"copy_failed", stats.CopyFailed},
+		{"retained", stats.Retained},
+	}
+}
+
+// MarshalJSON exports the stats as a JSON object of the StatsVersion schema,
+// every counter being a number keyed by its snake cased name:
+//
+//	{"version":1,"unobserved":12,"short_circuited":10,"mirrored":0,"mirror_dropped":0,"copy_failed":0,"retained":0}
+//
+// Scrapers should check version and ignore keys they don't know
+func (stats Stats) MarshalJSON() ([]byte, error) {
+	out := []byte{'{'}
+	for i, field := range stats.fields() {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = strconv.AppendQuote(out, field.name)
+		out = append(out, ':')
+		out = strconv.AppendUint(out, field.value, 10)
+	}
+	return append(out, '}'), nil
+}
+
+// MarshalText exports the stats as text/plain, one counter per line with the
+// same names as MarshalJSON, version first:
+//
+//	version 1
+//	unobserved 12
+//	short_circuited 10
+//	mirrored 0
+//	mirror_dropped 0
+//	copy_failed 0
+//	retained 0
+func (stats Stats) MarshalText() ([]byte, error) {
+	var out []byte
+	for _, field := range stats.fields() {
+		out = append(out, field.name...)
+		out = append(out, ' ')
+		out = strconv.AppendUint(out, field.value, 10)
+		out = append(out, '\n')
+	}
+	return out, nil
+}
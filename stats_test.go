@@ -0,0 +1,40 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	notify "github.com/jesus-ramos/go-notify"
+)
+
+func TestStatsUnobserved(t *testing.T) {
+	notifier := notify.NewNotifier()
+	defer notifier.Close()
+
+	notifier.Post("orders", 1)
+	notifier.Post("orders", 2)
+	if stats := notifier.Stats(); stats.Unobserved != 2 || stats.ShortCircuited != 2 {
+		t.Fatalf("counted %+v, want 2 unobserved and short circuited posts", stats)
+	}
+}
+
+func TestStatsExport(t *testing.T) {
+	stats := notify.Stats{Unobserved: 12, ShortCircuited: 10, Mirrored: 3, MirrorDropped: 1, CopyFailed: 2, Retained: 64}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"version":1,"unobserved":12,"short_circuited":10,"mirrored":3,"mirror_dropped":1,"copy_failed":2,"retained":64}`; string(data) != want {
+		t.Fatalf("exported %s, want %s", data, want)
+	}
+
+	text, err := stats.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "version 1\nunobserved 12\nshort_circuited 10\nmirrored 3\nmirror_dropped 1\ncopy_failed 2\nretained 64\n"
+	if string(text) != want {
+		t.Fatalf("exported %q, want %q", text, want)
+	}
+}
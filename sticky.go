@@ -0,0 +1,143 @@
+package notify
+
+// StickyStore persists the last notification of sticky events so they
+// survive restarts
+type StickyStore interface {
+	// Load returns nil if event has no saved notification
+	Load(event string) ([]byte, error)
+	Save(event string, payload []byte) error
+}
+
+// WithSticky retains the last notification posted to each listed event and
+// delivers it to every observer as it starts, so components depending on
+// "current state" events don't have to wait for the next change. Posts to
+// sticky events are retained even without observers and never fail with the
+// no subscribers policy
+func WithSticky(events ...string) Option {
+	return func(notifier *Notifier) {
+		for _, event := range events {
+			notifier.stickyEvents[event] = true
+		}
+	}
+}
+
+// WithStickyStore saves the notifications retained for sticky events to
+// store, encoded with codec, and restores them when the notifier is created.
+// If a save fails the post fails and nothing is delivered. Restore errors are
+// reported to the error handler (see WithErrorHandler)
+func WithStickyStore(store StickyStore, codec Codec) Option {
+	return func(notifier *Notifier) {
+		notifier.stickyStore = store
+		notifier.stickyCodec = codec
+	}
+}
+
+// A notification retained for a sticky event
+type stickyValue struct {
+	rec     record
+	payload []byte // copy on post snapshot
+	size    int    // counted against the memory budget, see WithMaxMemory
+}
+
+// Whether event is sticky, itself or through one of its aliases (see Alias)
+func (notifier *Notifier) isSticky(event string) bool {
+	if notifier.stickyEvents[event] {
+		return true
+	}
+	if aliased := notifier.stickyAliases.Load(); aliased != nil {
+		return (*aliased)[event]
+	}
+	return false
+}
+
+// StickyValue returns the notification retained for a sticky event
+func (notifier *Notifier) StickyValue(event string) (interface{}, bool) {
+	notifier.stickyLock.Lock()
+	defer notifier.stickyLock.Unlock()
+
+	value := notifier.sticky[notifier.canonical(event)]
+	if value == nil {
+		return nil, false
+	}
+	data, err := notifier.restore(value.payload, value.rec.data)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Save a notification to the sticky store if its event is sticky
+func (notifier *Notifier) saveSticky(event string, data interface{}) error {
+	if notifier.stickyStore == nil || !notifier.isSticky(event) {
+		return nil
+	}
+	payload, err := notifier.stickyCodec.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return notifier.stickyStore.Save(event, payload)
+}
+
+// Retain a notification if its event is sticky
+func (notifier *Notifier) keepSticky(event string, rec record, payload []byte) {
+	if !notifier.isSticky(event) {
+		return
+	}
+	value := &stickyValue{rec: rec, payload: payload}
+	if notifier.memory != nil {
+		value.size = notifier.memory.size(rec.data)
+	}
+
+	notifier.stickyLock.Lock()
+	defer notifier.stickyLock.Unlock()
+
+	if old := notifier.sticky[event]; old != nil {
+		notifier.memory.charge(-old.size)
+	}
+	notifier.memory.charge(value.size)
+	notifier.sticky[event] = value
+}
+
+// Load the saved notifications of sticky events
+func (notifier *Notifier) restoreSticky() {
+	for event := range notifier.stickyEvents {
+		if err := notifier.loadSticky(event); err != nil {
+			notifier.reportError(event, err)
+		}
+	}
+}
+
+func (notifier *Notifier) loadSticky(event string) error {
+	payload, err := notifier.stickyStore.Load(event)
+	if err != nil || payload == nil {
+		return err
+	}
+	data, err := notifier.stickyCodec.Unmarshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := notifier.fitSticky(event, data); err != nil {
+		return err
+	}
+	snapshot, err := notifier.snapshot(data)
+	if err != nil {
+		return err
+	}
+	notifier.keepSticky(event, record{data: data}, snapshot)
+	return nil
+}
+
+// Deliver the notification retained for event to an observer that just
+// started. Posts made meanwhile may be delivered ahead of it
+func (notifier *Notifier) deliverSticky(event string, sub *subscriber, value *stickyValue) {
+	if !sub.accepts(backgroundPost.severity) {
+		return
+	}
+	rec := value.rec
+	data, err := notifier.restore(value.payload, rec.data)
+	if err != nil {
+		return
+	}
+	rec.data = data
+	sub.send(notifier.value(event, sub, rec, backgroundPost))
+}
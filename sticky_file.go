@@ -0,0 +1,61 @@
+//go:build !notifyminimal
+
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// FileStickyStore is a StickyStore backed by a JSON file
+type FileStickyStore struct {
+	path     string
+	payloads map[string][]byte
+	sync.Mutex
+}
+
+// NewFileStickyStore loads (or creates on first save) the store at path
+func NewFileStickyStore(path string) (*FileStickyStore, error) {
+	store := &FileStickyStore{
+		path:     path,
+		payloads: make(map[string][]byte),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.payloads); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (store *FileStickyStore) Load(event string) ([]byte, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	return store.payloads[event], nil
+}
+
+func (store *FileStickyStore) Save(event string, payload []byte) error {
+	store.Lock()
+	defer store.Unlock()
+
+	store.payloads[event] = payload
+	data, err := json.Marshal(store.payloads)
+	if err != nil {
+		return err
+	}
+	tmp := store.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, store.path)
+}
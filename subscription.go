@@ -0,0 +1,157 @@
+package notify
+
+import "fmt"
+
+// WithChannelOwnership hands the output channel over to the notifier, which
+// closes it once the observer is stopped (by Stop, StopAll, Unsubscribe or
+// Close). Only use it for channels no one else sends on or observes other
+// events with
+func WithChannelOwnership() SubscribeOption {
+	return func(sub *subscriber) {
+		sub.owned = true
+	}
+}
+
+// WithErrors reports problems delivering to the observer on the channel
+// returned by its Subscription's Errors, as *DeliveryErrors: notifications
+// skipped because PostContext or PostTimeout gave up on it, payloads that
+// couldn't be copied for it (see WithCopyOnPost) and errors returned by its
+// Handler. Up to buffer errors are held until received; the rest are dropped
+// rather than holding up posts
+func WithErrors(buffer int) SubscribeOption {
+	return func(sub *subscriber) {
+		sub.errs = make(chan error, buffer)
+	}
+}
+
+// DeliveryError is a problem delivering a notification to a single observer
+// (see WithErrors)
+type DeliveryError struct {
+	Event string
+	Data  interface{}
+	Err   error
+}
+
+func (err *DeliveryError) Error() string {
+	return fmt.Sprintf("delivery of %q: %v", err.Event, err.Err)
+}
+
+func (err *DeliveryError) Unwrap() error {
+	return err.Err
+}
+
+// Report a problem delivering data to the observer, if it was started
+// WithErrors
+func (sub *subscriber) report(event string, data interface{}, err error) {
+	if sub.errs == nil {
+		return
+	}
+	sub.sendLock.RLock()
+	defer sub.sendLock.RUnlock()
+
+	if sub.stopped {
+		return
+	}
+	select {
+	case sub.errs <- &DeliveryError{Event: event, Data: data, Err: err}:
+	default:
+	}
+}
+
+// StartOwned observes the specified event on a channel allocated with the
+// provided buffer size and owned by the notifier, so it is closed once the
+// observer is stopped
+func (notifier *Notifier) StartOwned(event string, buffer int, options ...SubscribeOption) (<-chan interface{}, *Subscription) {
+	outputChan := make(chan interface{}, buffer)
+	subscription := notifier.Start(event, outputChan, append(options, WithChannelOwnership())...)
+	return outputChan, subscription
+}
+
+// Subscription is a handle to a single observer. Unlike Stop, which stops
+// every observer of an event using the same output channel, it only affects
+// the observer it was returned for
+type Subscription struct {
+	notifier *Notifier
+	event    string
+	sub      *subscriber
+	err      error // why the observer wasn't started
+}
+
+// Err returns why the observer couldn't be started (ie: ErrNilChannel), nil
+// if it was
+func (subscription *Subscription) Err() error {
+	return subscription.err
+}
+
+// Unsubscribe stops the observer
+func (subscription *Subscription) Unsubscribe() error {
+	if subscription.err != nil {
+		return subscription.err
+	}
+	notifier := subscription.notifier
+	shard, event := notifier.lockEvent(subscription.event)
+	defer notifier.unlockEvent(shard)
+
+	found := false
+	err := notifier.stop(event, func(sub *subscriber) bool {
+		found = found || sub == subscription.sub
+		return sub == subscription.sub
+	})
+	if err == nil && !found {
+		return ErrEventNotFound
+	}
+	return err
+}
+
+// Pause stops delivering notifications to the observer until Resume is
+// called. Notifications posted while paused are skipped, not queued
+func (subscription *Subscription) Pause() {
+	if subscription.sub == nil {
+		return
+	}
+	if !subscription.sub.paused.Swap(true) && subscription.sub.trace != nil {
+		subscription.sub.trace.paused()
+	}
+}
+
+// Resume delivers notifications to a paused observer again
+func (subscription *Subscription) Resume() {
+	if subscription.sub == nil {
+		return
+	}
+	if subscription.sub.paused.Swap(false) && subscription.sub.trace != nil {
+		subscription.sub.trace.resumed()
+	}
+}
+
+// Paused reports whether the observer is paused
+func (subscription *Subscription) Paused() bool {
+	if subscription.sub == nil {
+		return false
+	}
+	return subscription.sub.paused.Load()
+}
+
+// C returns the channel notifications are delivered on, nil for observers
+// started with StartFunc
+func (subscription *Subscription) C() <-chan interface{} {
+	if subscription.sub == nil || subscription.sub.handler != nil {
+		return nil
+	}
+	return subscription.sub.outputChan
+}
+
+// Errors returns the channel problems delivering to the observer are reported
+// on, which is closed once it is stopped. It is nil unless the observer was
+// started WithErrors
+func (subscription *Subscription) Errors() <-chan error {
+	if subscription.sub == nil {
+		return nil
+	}
+	return subscription.sub.errs
+}
+
+// Event returns the name of the observed event
+func (subscription *Subscription) Event() string {
+	return subscription.event
+}
@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSinkQueue is the number of notifications queued per sink when a
+// SinkConfig doesn't set one
+const DefaultSinkQueue = 256
+
+// SinkConfig declares a destination fed by a Tee. Each sink has its own queue,
+// retries and circuit breaker so a slow or failing sink doesn't hold up the
+// others
+type SinkConfig struct {
+	Name    string
+	Handler Handler
+	// Notifications queued while the sink is busy. Notifications that don't
+	// fit are dropped
+	Queue int
+	// Attempts made after the first one fails, waiting Backoff before the
+	// first retry and twice as long before every following one
+	MaxRetries int
+	Backoff    time.Duration
+	// Consecutive failed notifications that open the circuit, zero disables
+	// the breaker. While open, notifications are dropped until Cooldown has
+	// passed, after which one notification is tried to close it again
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// SinkStats counts what happened to the notifications fed to a sink
+type SinkStats struct {
+	Delivered uint64
+	Retried   uint64 // retry attempts, successful or not
+	Failed    uint64 // notifications still failing after their last retry
+	Dropped   uint64 // notifications that didn't fit the queue or met an open circuit
+	Queued    int
+	Open      bool // the circuit breaker is open
+}
+
+// TeeStats counts the notifications fed to a Tee's sinks, in aggregate and by
+// sink name
+type TeeStats struct {
+	Delivered uint64
+	Failed    uint64
+	Dropped   uint64
+	Sinks     map[string]SinkStats
+}
+
+// Tee feeds every notification of an event to several sinks
+type Tee struct {
+	subscription *Subscription
+	sinks        []*teeSink
+	clock        Clock
+	closing      chan struct{}
+	unregister   func()
+	// Held for reading while queueing so queues aren't closed under a
+	// handler still running after Close unsubscribed it
+	feedLock sync.RWMutex
+	closed   bool
+
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+type teeSink struct {
+	config SinkConfig
+	queue  chan interface{}
+
+	delivered atomic.Uint64
+	retried   atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+
+	// Only used by the sink's goroutine, except openUntil which Stats reads
+	failures  int
+	openUntil atomic.Int64 // UnixNano the circuit stays open until
+}
+
+// Tee observes event, handing every notification to each of the sinks.
+// Errors returned by a sink's last attempt are reported to the error handler
+func (notifier *Notifier) Tee(event string, sinks ...SinkConfig) *Tee {
+	tee := &Tee{clock: notifier.clock, closing: make(chan struct{})}
+	for _, config := range sinks {
+		if config.Queue <= 0 {
+			config.Queue = DefaultSinkQueue
+		}
+		sink := &teeSink{config: config, queue: make(chan interface{}, config.Queue)}
+		tee.sinks = append(tee.sinks, sink)
+
+		tee.wg.Add(1)
+		go tee.run(notifier, event, sink)
+	}
+
+	tee.subscription = notifier.StartFunc(event, func(ctx context.Context, data interface{}) error {
+		tee.feedLock.RLock()
+		defer tee.feedLock.RUnlock()
+
+		if tee.closed {
+			return nil
+		}
+		for _, sink := range tee.sinks {
+			select {
+			case sink.queue <- data:
+			default:
+				sink.dropped.Add(1)
+			}
+		}
+		return nil
+	}, WithComponent("tee"))
+	tee.unregister = notifier.RegisterSelfTest("tee "+event, tee.selfTest)
+	return tee
+}
+
+// Close stops observing the event and waits for the sinks to be handed the
+// notifications already queued for them. Pending retries are abandoned
+func (tee *Tee) Close() error {
+	tee.closeOnce.Do(func() {
+		tee.unregister()
+		tee.subscription.Unsubscribe()
+		close(tee.closing)
+
+		tee.feedLock.Lock()
+		tee.closed = true
+		for _, sink := range tee.sinks {
+			close(sink.queue)
+		}
+		tee.feedLock.Unlock()
+
+		tee.wg.Wait()
+	})
+	return nil
+}
+
+// Stats returns the delivery counts of every sink
+func (tee *Tee) Stats() TeeStats {
+	stats := TeeStats{Sinks: make(map[string]SinkStats, len(tee.sinks))}
+	now := tee.clock.Now().UnixNano()
+	for _, sink := range tee.sinks {
+		sinkStats := SinkStats{
+			Delivered: sink.delivered.Load(),
+			Retried:   sink.retried.Load(),
+			Failed:    sink.failed.Load(),
+			Dropped:   sink.dropped.Load(),
+			Queued:    len(sink.queue),
+			Open:      sink.openUntil.Load() > now,
+		}
+		stats.Delivered += sinkStats.Delivered
+		stats.Failed += sinkStats.Failed
+		stats.Dropped += sinkStats.Dropped
+		stats.Sinks[sink.config.Name] = sinkStats
+	}
+	return stats
+}
+
+func (tee *Tee) run(notifier *Notifier, event string, sink *teeSink) {
+	defer tee.wg.Done()
+
+	for data := range sink.queue {
+		if sink.openUntil.Load() > tee.clock.Now().UnixNano() {
+			sink.dropped.Add(1)
+			continue
+		}
+
+		err := tee.deliver(sink, data)
+		if err == nil {
+			sink.delivered.Add(1)
+			sink.failures = 0
+			continue
+		}
+		sink.failed.Add(1)
+		sink.failures++
+		notifier.reportError(event, err)
+		if sink.config.BreakerThreshold > 0 && sink.failures >= sink.config.BreakerThreshold {
+			sink.openUntil.Store(tee.clock.Now().Add(sink.config.BreakerCooldown).UnixNano())
+		}
+	}
+}
+
+// Hand data to the sink, retrying until it succeeds, it runs out of retries or
+// the tee is closed. A sink whose circuit was open only gets one attempt
+func (tee *Tee) deliver(sink *teeSink, data interface{}) error {
+	retries := sink.config.MaxRetries
+	if sink.config.BreakerThreshold > 0 && sink.failures >= sink.config.BreakerThreshold {
+		retries = 0
+	}
+
+	backoff := sink.config.Backoff
+	err := sink.config.Handler(context.Background(), data)
+	for attempt := 0; err != nil && attempt < retries; attempt++ {
+		timer := tee.clock.NewTimer(backoff)
+		select {
+		case <-timer.C():
+		case <-tee.closing:
+			timer.Stop()
+			return err
+		}
+		backoff *= 2
+
+		sink.retried.Add(1)
+		err = sink.config.Handler(context.Background(), data)
+	}
+	return err
+}
+
+// Check that no sink's circuit breaker is open
+func (tee *Tee) selfTest(ctx context.Context) error {
+	var open []string
+	for name, stats := range tee.Stats().Sinks {
+		if stats.Open {
+			open = append(open, name)
+		}
+	}
+	if len(open) > 0 {
+		sort.Strings(open)
+		return fmt.Errorf("circuit open for sinks %v", open)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"strings"
+	"time"
+)
+
+// TimeEventPrefix names the built-in periodic events. Observing
+// TimeEventPrefix+"1s" (ie: "notify.time.1s") delivers the current time.Time
+// every second; any duration understood by time.ParseDuration can be used.
+// The underlying ticker is started with the first observer and stopped once
+// the last one goes away. Like time.Ticker, ticks are dropped for observers
+// that aren't ready to receive them. Ticks follow the notifier's clock (see
+// WithClock)
+const TimeEventPrefix = "notify.time."
+
+// Parse the interval of a built-in time event
+func timeEventInterval(event string) (time.Duration, bool) {
+	if !strings.HasPrefix(event, TimeEventPrefix) {
+		return 0, false
+	}
+	interval, err := time.ParseDuration(strings.TrimPrefix(event, TimeEventPrefix))
+	if err != nil || interval <= 0 {
+		return 0, false
+	}
+	return interval, true
+}
+
+// Start the ticker for a built-in time event if it isn't running yet. Must be
+// called with the event's shard locked
+func (notifier *Notifier) startTicker(event string) {
+	notifier.tickerLock.Lock()
+	defer notifier.tickerLock.Unlock()
+
+	if _, running := notifier.tickers[event]; running {
+		return
+	}
+	interval, ok := timeEventInterval(event)
+	if !ok {
+		return
+	}
+
+	stop := make(chan struct{})
+	notifier.tickers[event] = stop
+	go notifier.runTicker(event, interval, stop)
+}
+
+// Stop the ticker for a built-in time event. Must be called with the event's
+// shard locked
+func (notifier *Notifier) stopTicker(event string) {
+	notifier.tickerLock.Lock()
+	defer notifier.tickerLock.Unlock()
+
+	if stop, running := notifier.tickers[event]; running {
+		close(stop)
+		delete(notifier.tickers, event)
+	}
+}
+
+func (notifier *Notifier) runTicker(event string, interval time.Duration, stop chan struct{}) {
+	ticker := notifier.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C():
+			notifier.postTick(event, now)
+		}
+	}
+}
+
+func (notifier *Notifier) postTick(event string, now time.Time) {
+	entry, ok := notifier.lookup(event)
+	if !ok {
+		return
+	}
+	rec, subs := entry.publish(now, notifier.historySize)
+	for _, sub := range subs {
+		if !sub.accepts(backgroundPost.severity) {
+			continue
+		}
+		sub.trySend(notifier.value(event, sub, rec, backgroundPost))
+	}
+}
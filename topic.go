@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrTopicType = errors.New("Notification doesn't match the topic's type")
+
+// Topic is an event whose notifications are all of type T. Declaring topics
+// once and using their methods instead of event names makes the compiler
+// check that producers and observers agree on the payload:
+//
+//	var OrderCreated = notify.Topic[Order]{Name: "orders.created"}
+//
+//	OrderCreated.Post(notifier, order)
+//	orders, subscription := OrderCreated.Subscribe(notifier, 16)
+//
+// Topics share the notifier with plain events of the same name, so
+// notifications of other types can still reach the event through Post.
+// Observers of the topic don't receive them and report them to the error
+// handler (see WithErrorHandler) as ErrTopicType
+type Topic[T any] struct {
+	Name string
+}
+
+// Post a notification to the topic
+func (topic Topic[T]) Post(notifier *Notifier, value T) error {
+	return notifier.Post(topic.Name, value)
+}
+
+// PostContext posts a notification to the topic, giving up on observers that
+// are still blocking once ctx is done (see Notifier.PostContext)
+func (topic Topic[T]) PostContext(ctx context.Context, notifier *Notifier, value T) error {
+	return notifier.PostContext(ctx, topic.Name, value)
+}
+
+// PostTimeout posts a notification to the topic, giving up on observers that
+// are still blocking after timeout (see Notifier.PostTimeout)
+func (topic Topic[T]) PostTimeout(notifier *Notifier, value T, timeout time.Duration) error {
+	return notifier.PostTimeout(topic.Name, value, timeout)
+}
+
+// Subscribe observes the topic on a channel with the provided buffer size,
+// which is closed once the observer is stopped
+func (topic Topic[T]) Subscribe(notifier *Notifier, buffer int, options ...SubscribeOption) (<-chan T, *Subscription) {
+	outputChan := make(chan T, buffer)
+	adapter := adapt(outputChan, func(data interface{}) {
+		notifier.reportError(topic.Name, topic.mismatch(data))
+	})
+	subscription := notifier.Start(topic.Name, adapter, append(options, WithChannelOwnership())...)
+	return outputChan, subscription
+}
+
+// Handle observes the topic by calling handler with every notification (see
+// Notifier.StartFunc)
+func (topic Topic[T]) Handle(notifier *Notifier, handler func(ctx context.Context, value T) error, options ...SubscribeOption) *Subscription {
+	return notifier.StartFunc(topic.Name, func(ctx context.Context, data interface{}) error {
+		value, ok := data.(T)
+		if !ok {
+			return topic.mismatch(data)
+		}
+		return handler(ctx, value)
+	}, options...)
+}
+
+func (topic Topic[T]) mismatch(data interface{}) error {
+	var want T
+	return fmt.Errorf("%w: %q carries %T, got %T", ErrTopicType, topic.Name, want, data)
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// TraceKind identifies the point in an observer's lifetime a
+// SubscriptionTrace records
+type TraceKind int
+
+const (
+	// The observer was started
+	TraceStarted TraceKind = iota
+	// The observer was paused (see Subscription.Pause)
+	TracePaused
+	// The observer was resumed. Duration is how long it was paused
+	TraceResumed
+	// A single delivery to the observer took at least the lag threshold.
+	// Duration is how long it took
+	TraceLagged
+	// The observer was stopped. Duration is how long it was observing
+	TraceStopped
+)
+
+func (kind TraceKind) String() string {
+	switch kind {
+	case TraceStarted:
+		return "started"
+	case TracePaused:
+		return "paused"
+	case TraceResumed:
+		return "resumed"
+	case TraceLagged:
+		return "lagged"
+	case TraceStopped:
+		return "stopped"
+	}
+	return "TraceKind(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// SubscriptionTrace is an entry in the trace of an observer's lifetime (see
+// WithSubscriptionTracing)
+type SubscriptionTrace struct {
+	Kind  TraceKind
+	Event string
+	// Identifies the observer across its entries, starting at 1
+	ID       uint64
+	Labels   map[string]string // see WithLabels
+	Time     time.Time
+	Duration time.Duration
+}
+
+// WithSubscriptionTracing hands hook an entry every time an observer is
+// started, paused, resumed or stopped, and for every delivery to it taking at
+// least lagThreshold (zero disables lag entries), so the lifetime of a
+// misbehaving consumer can be reconstructed from logs after the fact. hook is
+// called in order on a goroutine of its own and must not block for long
+func WithSubscriptionTracing(hook func(entry SubscriptionTrace), lagThreshold time.Duration) Option {
+	return func(notifier *Notifier) {
+		notifier.tracer = &subscriptionTracer{notifier: notifier, hook: hook, lagThreshold: lagThreshold}
+	}
+}
+
+type subscriptionTracer struct {
+	notifier     *Notifier
+	hook         func(entry SubscriptionTrace)
+	lagThreshold time.Duration
+	ids          atomic.Uint64
+}
+
+// The tracing state of a single observer
+type subscriptionTrace struct {
+	tracer   *subscriptionTracer
+	id       uint64
+	event    string
+	labels   map[string]string
+	started  time.Time
+	pausedAt atomic.Int64 // UnixNano
+}
+
+// Start tracing an observer of event
+func (tracer *subscriptionTracer) start(event string, sub *subscriber) {
+	trace := &subscriptionTrace{
+		tracer:  tracer,
+		id:      tracer.ids.Add(1),
+		event:   event,
+		labels:  sub.labelsCopy(),
+		started: tracer.notifier.clock.Now(),
+	}
+	sub.trace = trace
+	trace.emit(TraceStarted, trace.started, 0)
+}
+
+// Queue an entry for the hook, which runs on the lifecycle goroutine so
+// entries keep their order and the hook never runs under the notifier's locks
+func (trace *subscriptionTrace) emit(kind TraceKind, now time.Time, duration time.Duration) {
+	entry := SubscriptionTrace{
+		Kind:     kind,
+		Event:    trace.event,
+		ID:       trace.id,
+		Labels:   trace.labels,
+		Time:     now,
+		Duration: duration,
+	}
+	hook := trace.tracer.hook
+	notifier := trace.tracer.notifier
+
+	notifier.lifecycleLock.Lock()
+	defer notifier.lifecycleLock.Unlock()
+
+	notifier.queueLifecycle(trace.event, []func(event string){func(string) { hook(entry) }})
+}
+
+func (trace *subscriptionTrace) paused() {
+	now := trace.tracer.notifier.clock.Now()
+	trace.pausedAt.Store(now.UnixNano())
+	trace.emit(TracePaused, now, 0)
+}
+
+func (trace *subscriptionTrace) resumed() {
+	now := trace.tracer.notifier.clock.Now()
+	trace.emit(TraceResumed, now, now.Sub(time.Unix(0, trace.pausedAt.Load())))
+}
+
+// Record a delivery that began at start
+func (trace *subscriptionTrace) delivered(start time.Time) {
+	if trace.tracer.lagThreshold <= 0 {
+		return
+	}
+	now := trace.tracer.notifier.clock.Now()
+	if elapsed := now.Sub(start); elapsed >= trace.tracer.lagThreshold {
+		trace.emit(TraceLagged, start, elapsed)
+	}
+}
+
+func (trace *subscriptionTrace) stopped() {
+	now := trace.tracer.notifier.clock.Now()
+	trace.emit(TraceStopped, now, now.Sub(trace.started))
+}
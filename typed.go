@@ -0,0 +1,37 @@
+package notify
+
+// Adapt returns a channel suitable for Start that forwards every notification
+// of type T to outputChan and drops the rest. outputChan is closed once the
+// returned channel is closed, ie: by Stop if it was started
+// WithChannelOwnership
+func Adapt[T any](outputChan chan T) chan interface{} {
+	return adapt(outputChan, nil)
+}
+
+// StartTyped observes event on notifier, delivering notifications of type T to
+// outputChan. Notifications of any other type are passed to onMismatch, or
+// dropped if it is nil. outputChan is closed once the returned Subscription
+// is unsubscribed, or straight away if the observer couldn't be started
+func StartTyped[T any](notifier *Notifier, event string, outputChan chan T, onMismatch func(data interface{}), options ...SubscribeOption) *Subscription {
+	if notifier == nil {
+		close(outputChan)
+		return failedSubscription(event, ErrNilNotifier)
+	}
+	return notifier.Start(event, adapt(outputChan, onMismatch), append(options, WithChannelOwnership())...)
+}
+
+func adapt[T any](outputChan chan T, onMismatch func(data interface{})) chan interface{} {
+	adapter := make(chan interface{}, cap(outputChan))
+	go func() {
+		defer close(outputChan)
+
+		for data := range adapter {
+			if value, ok := data.(T); ok {
+				outputChan <- value
+			} else if onMismatch != nil {
+				onMismatch(data)
+			}
+		}
+	}()
+	return adapter
+}
@@ -0,0 +1,255 @@
+// Package upgrade hands a notifier's state and listening sockets over to a
+// new process during graceful binary upgrades, so deploys neither drop
+// connections nor lose the sequence numbers and sticky notifications
+// observers depend on.
+//
+// The running process starts its replacement with Upgrade, which passes the
+// listeners opened with Listen and the state exported by the notifier. The
+// replacement picks both up with New, imports the state and calls Ready once
+// it is serving, at which point the old process is told to exit:
+//
+//	upg, err := upgrade.New()
+//	...
+//	if state := upg.State(); state != nil {
+//		notifier.ImportState(state, notify.JSONCodec{})
+//	}
+//	ln, err := upg.Listen("tcp", ":8080")
+//	go http.Serve(ln, handler)
+//	upg.Ready()
+//
+//	// on SIGHUP
+//	state, _ := notifier.ExportState(notify.JSONCodec{})
+//	if err := upg.Upgrade(state); err == nil {
+//		<-upg.Exit()
+//		// stop serving, close the notifier and exit
+//	}
+//
+// File descriptors are inherited through exec, so upgrades are only supported
+// on Unix systems.
+package upgrade
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotChild       = errors.New("Not started by an upgrade")
+	ErrUpgradeFailed  = errors.New("New process exited before it was ready")
+	ErrNotInheritable = errors.New("Listener can't be passed to another process")
+)
+
+// ReadyTimeout is how long Upgrade waits for the new process to call Ready
+var ReadyTimeout = time.Minute
+
+// Names the inherited listeners, in file descriptor order
+const envListeners = "NOTIFY_UPGRADE_LISTENERS"
+
+// Descriptors of the inherited files. Listeners follow the ready pipe
+const (
+	stateFd     = 3
+	readyFd     = 4
+	listenersFd = 5
+)
+
+// Upgrader keeps track of the listeners to hand over and the process
+// generation it belongs to
+type Upgrader struct {
+	state     []byte
+	inherited map[string]*os.File // listeners passed by the parent, by address
+	ready     *os.File            // pipe to the parent, nil for the first generation
+
+	lock      sync.Mutex // held for the whole of an upgrade
+	listeners map[string]net.Listener
+	exit      chan struct{}
+	exitOnce  sync.Once
+}
+
+// New returns the upgrader for this process, picking up the state and
+// listeners handed over by the parent if it was started by Upgrade
+func New() (*Upgrader, error) {
+	upg := &Upgrader{
+		inherited: make(map[string]*os.File),
+		listeners: make(map[string]net.Listener),
+		exit:      make(chan struct{}),
+	}
+
+	names, ok := os.LookupEnv(envListeners)
+	if !ok {
+		return upg, nil
+	}
+	os.Unsetenv(envListeners)
+
+	stateFile := os.NewFile(stateFd, "upgrade-state")
+	state, err := io.ReadAll(stateFile)
+	stateFile.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(state) > 0 {
+		upg.state = state
+	}
+	upg.ready = os.NewFile(readyFd, "upgrade-ready")
+
+	if names != "" {
+		for i, name := range strings.Split(names, ",") {
+			upg.inherited[name] = os.NewFile(uintptr(listenersFd+i), name)
+		}
+	}
+
+	return upg, nil
+}
+
+// State returns the state handed over by the parent, nil for the first
+// generation
+func (upg *Upgrader) State() []byte {
+	return upg.state
+}
+
+// Listen returns the listener for addr inherited from the parent, or opens a
+// new one. Listeners opened this way are handed over by Upgrade
+func (upg *Upgrader) Listen(network string, addr string) (net.Listener, error) {
+	upg.lock.Lock()
+	defer upg.lock.Unlock()
+
+	name := network + ":" + addr
+	if listener, ok := upg.listeners[name]; ok {
+		return listener, nil
+	}
+
+	var listener net.Listener
+	var err error
+	if file, ok := upg.inherited[name]; ok {
+		delete(upg.inherited, name)
+		listener, err = net.FileListener(file)
+		file.Close()
+	} else {
+		listener, err = net.Listen(network, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	upg.listeners[name] = listener
+	return listener, nil
+}
+
+// Ready tells the parent that this process has taken over, so it can exit.
+// Inherited listeners that weren't claimed with Listen are closed
+func (upg *Upgrader) Ready() error {
+	upg.lock.Lock()
+	defer upg.lock.Unlock()
+
+	for name, file := range upg.inherited {
+		file.Close()
+		delete(upg.inherited, name)
+	}
+	if upg.ready == nil {
+		return ErrNotChild
+	}
+	_, err := upg.ready.Write([]byte{1})
+	upg.ready.Close()
+	upg.ready = nil
+	return err
+}
+
+// Upgrade starts a new instance of the running executable, with the same
+// arguments, handing it state and every listener opened with Listen. It
+// returns once the new process has called Ready and closes the channel
+// returned by Exit, after which this process should stop serving and exit.
+// If the new process exits first or doesn't become ready within
+// ReadyTimeout it is killed and this process carries on
+func (upg *Upgrader) Upgrade(state []byte) error {
+	upg.lock.Lock()
+	defer upg.lock.Unlock()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(upg.listeners))
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for name, listener := range upg.listeners {
+		inheritable, ok := listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return ErrNotInheritable
+		}
+		file, err := inheritable.File()
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		files = append(files, file)
+	}
+
+	stateRead, stateWrite, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		stateRead.Close()
+		stateWrite.Close()
+		return err
+	}
+	defer readyRead.Close()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), envListeners+"="+strings.Join(names, ","))
+	cmd.ExtraFiles = append([]*os.File{stateRead, readyWrite}, files...)
+	err = cmd.Start()
+	stateRead.Close()
+	readyWrite.Close()
+	if err != nil {
+		stateWrite.Close()
+		return err
+	}
+
+	go func() {
+		stateWrite.Write(state)
+		stateWrite.Close()
+	}()
+
+	if err := waitReady(readyRead); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	go cmd.Wait()
+
+	upg.exitOnce.Do(func() { close(upg.exit) })
+	return nil
+}
+
+// Wait for the byte written by Ready. The pipe is closed without it if the
+// new process exits first
+func waitReady(ready *os.File) error {
+	ready.SetReadDeadline(time.Now().Add(ReadyTimeout))
+
+	var buf [1]byte
+	if _, err := ready.Read(buf[:]); err != nil {
+		if err == io.EOF {
+			return ErrUpgradeFailed
+		}
+		return err
+	}
+	return nil
+}
+
+// Exit returns a channel that is closed once a new process has taken over
+func (upg *Upgrader) Exit() <-chan struct{} {
+	return upg.exit
+}
@@ -0,0 +1,137 @@
+//go:build unix
+
+package upgrade_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/jesus-ramos/go-notify/upgrade"
+)
+
+// Set for the test binary started by Upgrade, telling it how to behave
+const envChild = "UPGRADE_TEST_CHILD"
+
+// Listeners are handed over by the address they were opened with
+const addr = "127.0.0.1:0"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(envChild) {
+	case "":
+		os.Exit(m.Run())
+	case "fail":
+		os.Exit(1)
+	}
+	if err := child(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Take over the parent's listener, then answer one connection with the
+// state handed over
+func child() error {
+	upg, err := upgrade.New()
+	if err != nil {
+		return err
+	}
+	listener, err := upg.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	if err := upg.Ready(); err != nil {
+		return err
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(upg.State())
+	return err
+}
+
+func TestUpgrade(t *testing.T) {
+	upg, err := upgrade.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := upg.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound := listener.Addr().String()
+	t.Setenv(envChild, "serve")
+
+	if err := upg.Upgrade([]byte("state")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-upg.Exit():
+	default:
+		t.Fatal("Exit not closed once the new process was ready")
+	}
+	listener.Close()
+
+	conn, err := net.Dial("tcp", bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "state" {
+		t.Fatalf("new process served %q, want the state handed over", got)
+	}
+}
+
+func TestUpgradeFailed(t *testing.T) {
+	upg, err := upgrade.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envChild, "fail")
+
+	if err := upg.Upgrade(nil); !errors.Is(err, upgrade.ErrUpgradeFailed) {
+		t.Fatalf("Upgrade returned %v, want ErrUpgradeFailed", err)
+	}
+	select {
+	case <-upg.Exit():
+		t.Fatal("Exit closed after a failed upgrade")
+	default:
+	}
+}
+
+func TestFirstGeneration(t *testing.T) {
+	upg, err := upgrade.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := upg.State(); state != nil {
+		t.Fatalf("first generation has state %q", state)
+	}
+	if err := upg.Ready(); !errors.Is(err, upgrade.ErrNotChild) {
+		t.Fatalf("Ready returned %v, want ErrNotChild", err)
+	}
+
+	listener, err := upg.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	again, err := upg.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != listener {
+		t.Fatal("Listen opened a second listener for the same address")
+	}
+}
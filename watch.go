@@ -0,0 +1,290 @@
+package notify
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidResumeToken = errors.New("Invalid resume token")
+	ErrResumeTokenExpired = errors.New("Resume token expired")
+)
+
+// WithHistory retains the last size notifications of every started event so
+// Watch can resume streams from a token
+func WithHistory(size int) Option {
+	return func(notifier *Notifier) {
+		notifier.historySize = size
+	}
+}
+
+// Change is a notification delivered by a ChangeStream
+type Change struct {
+	// Token resumes a stream immediately after this change
+	Token string
+	Data  interface{}
+}
+
+// ChangeStream delivers the notifications posted to a watched event along with
+// resume tokens
+type ChangeStream struct {
+	notifier     *Notifier
+	event        string
+	subscription *Subscription // of its observer of the event
+	input        chan interface{}
+	changes      chan Change
+	done         chan struct{}
+	closeOnce    sync.Once
+	catchUpRate  int
+}
+
+// WatchOption configures a ChangeStream
+type WatchOption func(*ChangeStream)
+
+// WithCatchUpRate limits a resumed stream to delivering perSecond changes
+// until it has caught up, so replaying a long backlog doesn't overwhelm the
+// consumer. Changes are always delivered in the order they were posted: live
+// changes posted while the stream catches up are queued behind the backlog
+// and delivered at the same rate. Posts never wait on a stream that is
+// catching up. A perSecond of zero or less doesn't limit the stream, and one
+// over a billion delivers a change every nanosecond
+func WithCatchUpRate(perSecond int) WatchOption {
+	return func(stream *ChangeStream) {
+		if perSecond < 0 {
+			perSecond = 0
+		}
+		stream.catchUpRate = perSecond
+	}
+}
+
+// Watch observes event, returning a stream of changes. If resumeToken is not
+// empty the stream first replays every change retained since the one the
+// token was issued for. ErrResumeTokenExpired is returned if some of those
+// changes are no longer retained (see WithHistory) or the token was issued by
+// another notifier, in which case the caller should resynchronize and watch
+// without a token
+func (notifier *Notifier) Watch(event string, resumeToken string, options ...WatchOption) (*ChangeStream, error) {
+	event = notifier.resolve(event)
+	var tokenEvent string
+	var after uint64
+	if resumeToken != "" {
+		var epoch int64
+		var err error
+		if tokenEvent, epoch, after, err = parseResumeToken(resumeToken); err != nil {
+			return nil, err
+		}
+		if notifier.canonical(tokenEvent) != event {
+			return nil, ErrInvalidResumeToken
+		}
+		if epoch != notifier.epoch {
+			return nil, ErrResumeTokenExpired
+		}
+	}
+
+	shard, event := notifier.lockEvent(event)
+	defer notifier.unlockEvent(shard)
+
+	if _, ok := shard.events[event]; !ok && resumeToken != "" {
+		return nil, ErrResumeTokenExpired
+	}
+	// Tokens issued for an alias only apply if event took over its history
+	if resumeToken != "" && tokenEvent != event && !notifier.resumable[tokenEvent] {
+		return nil, ErrResumeTokenExpired
+	}
+	if err := notifier.admit(event, 1); err != nil {
+		return nil, err
+	}
+
+	stream := &ChangeStream{
+		notifier: notifier,
+		event:    event,
+		input:    make(chan interface{}),
+		changes:  make(chan Change),
+		done:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(stream)
+	}
+	sub := &subscriber{outputChan: stream.input, watch: true, owned: true}
+	entry := notifier.start(event, sub)
+	stream.subscription = &Subscription{notifier: notifier, event: event, sub: sub}
+
+	// Read the backlog only once the stream observes the event so nothing
+	// posted concurrently is missed. Changes in both are skipped by run
+	var backlog []record
+	if resumeToken != "" {
+		var err error
+		if backlog, err = entry.since(after); err != nil {
+			notifier.stop(event, func(s *subscriber) bool { return s == sub })
+			return nil, err
+		}
+	}
+	go stream.run(backlog)
+
+	return stream, nil
+}
+
+// Retained notifications posted after seq
+func (entry *eventEntry) since(seq uint64) ([]record, error) {
+	entry.Lock()
+	defer entry.Unlock()
+
+	if seq > entry.seq {
+		return nil, ErrInvalidResumeToken
+	}
+	if seq == entry.seq {
+		return nil, nil
+	}
+	if len(entry.history) == 0 || entry.history[0].seq > seq+1 {
+		return nil, ErrResumeTokenExpired
+	}
+
+	var backlog []record
+	for _, rec := range entry.history {
+		if rec.seq > seq {
+			backlog = append(backlog, rec)
+		}
+	}
+	return backlog, nil
+}
+
+// Changes returns the channel changes are delivered on. It is closed when the
+// stream is closed or the event is stopped
+func (stream *ChangeStream) Changes() <-chan Change {
+	return stream.changes
+}
+
+// Close stops watching the event. It may be called more than once, and
+// concurrently
+func (stream *ChangeStream) Close() error {
+	var err error
+	stream.closeOnce.Do(func() {
+		close(stream.done)
+		if err = stream.subscription.Unsubscribe(); err == ErrEventNotFound {
+			err = nil
+		}
+	})
+	return err
+}
+
+func (stream *ChangeStream) run(backlog []record) {
+	defer close(stream.changes)
+
+	last, ok := stream.catchUp(backlog)
+	if !ok {
+		return
+	}
+	for value := range stream.input {
+		if rec := value.(record); rec.seq > last && !stream.send(rec) {
+			return
+		}
+	}
+}
+
+// Deliver the backlog, and everything posted until it has been delivered, at
+// the catch-up rate. Returns the last sequence number delivered and false if
+// the stream should stop
+func (stream *ChangeStream) catchUp(backlog []record) (uint64, bool) {
+	if len(backlog) == 0 {
+		return 0, true
+	}
+	queue := backlog
+	last := backlog[len(backlog)-1].seq
+
+	var tick <-chan time.Time
+	if stream.catchUpRate > 0 {
+		interval := time.Second / time.Duration(stream.catchUpRate)
+		if interval <= 0 {
+			interval = time.Nanosecond
+		}
+		ticker := stream.notifier.clock.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+
+	input := stream.input
+	ready := true
+	for len(queue) > 0 {
+		var out chan Change
+		var change Change
+		if ready {
+			out = stream.changes
+			change = stream.change(queue[0])
+		}
+
+		select {
+		case out <- change:
+			queue = queue[1:]
+			ready = tick == nil
+		case <-tick:
+			ready = true
+		case value, ok := <-input:
+			if !ok {
+				// Stopped, deliver what is queued then close
+				input = nil
+				continue
+			}
+			if rec := value.(record); rec.seq > last {
+				queue = append(queue, rec)
+				last = rec.seq
+			}
+		case <-stream.done:
+			stream.drain()
+			return 0, false
+		}
+	}
+
+	return last, input != nil
+}
+
+func (stream *ChangeStream) change(rec record) Change {
+	return Change{
+		Token: stream.notifier.resumeToken(stream.event, rec.seq),
+		Data:  rec.data,
+	}
+}
+
+// Deliver a change, returning false once the stream is closed
+func (stream *ChangeStream) send(rec record) bool {
+	select {
+	case stream.changes <- stream.change(rec):
+		return true
+	case <-stream.done:
+		stream.drain()
+		return false
+	}
+}
+
+// Keep receiving until Close has removed the stream so blocked posts return
+func (stream *ChangeStream) drain() {
+	for range stream.input {
+	}
+}
+
+func (notifier *Notifier) resumeToken(event string, seq uint64) string {
+	token := strconv.FormatInt(notifier.epoch, 36) + "." + strconv.FormatUint(seq, 36) + "." + event
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+func parseResumeToken(token string) (event string, epoch int64, seq uint64, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("%w: %v", ErrInvalidResumeToken, err)
+	}
+	parts := strings.SplitN(string(raw), ".", 3)
+	if len(parts) != 3 {
+		return "", 0, 0, ErrInvalidResumeToken
+	}
+	if epoch, err = strconv.ParseInt(parts[0], 36, 64); err != nil {
+		return "", 0, 0, ErrInvalidResumeToken
+	}
+	if seq, err = strconv.ParseUint(parts[1], 36, 64); err != nil {
+		return "", 0, 0, ErrInvalidResumeToken
+	}
+	return parts[2], epoch, seq, nil
+}
@@ -0,0 +1,249 @@
+package notify_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	notify "github.com/jesus-ramos/go-notify"
+	"github.com/jesus-ramos/go-notify/notifytest"
+)
+
+// A notifier keeping history whose "orders" event stays started while streams
+// come and go
+func historyNotifier(t *testing.T, size int, options ...notify.Option) *notify.Notifier {
+	t.Helper()
+	notifier := notify.NewNotifier(append(options, notify.WithHistory(size))...)
+	t.Cleanup(func() { notifier.Close() })
+	notifier.Start("orders", make(chan interface{}, 100))
+	return notifier
+}
+
+func nextChange(t *testing.T, stream *notify.ChangeStream) notify.Change {
+	t.Helper()
+	select {
+	case change, ok := <-stream.Changes():
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("no change delivered")
+	}
+	return notify.Change{}
+}
+
+// Post values to event one after the other, in the background since posts
+// wait for the streams watching it
+func postAll(notifier *notify.Notifier, event string, values ...interface{}) {
+	go func() {
+		for _, value := range values {
+			notifier.Post(event, value)
+		}
+	}()
+}
+
+func TestWatchResume(t *testing.T) {
+	notifier := historyNotifier(t, 10)
+
+	stream, err := notifier.Watch("orders", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postAll(notifier, "orders", 1, 2)
+	nextChange(t, stream)
+	token := nextChange(t, stream).Token
+	stream.Close()
+
+	for _, value := range []int{3, 4, 5} {
+		notifier.Post("orders", value)
+	}
+	resumed, err := notifier.Watch("orders", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	postAll(notifier, "orders", 6)
+	for want := 3; want <= 6; want++ {
+		if got := nextChange(t, resumed).Data; got != want {
+			t.Fatalf("resumed stream delivered %v, want %d", got, want)
+		}
+	}
+}
+
+func TestWatchResumeUpToDate(t *testing.T) {
+	notifier := historyNotifier(t, 10)
+	notifier.Post("orders", 1)
+
+	stream, _ := notifier.Watch("orders", "")
+	postAll(notifier, "orders", 2)
+	token := nextChange(t, stream).Token
+	stream.Close()
+
+	resumed, err := notifier.Watch("orders", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	postAll(notifier, "orders", 3)
+	if got := nextChange(t, resumed).Data; got != 3 {
+		t.Fatalf("resumed stream delivered %v, want 3", got)
+	}
+}
+
+func TestWatchRejectsTokens(t *testing.T) {
+	notifier := historyNotifier(t, 2)
+	notifier.Start("payments", make(chan interface{}, 100))
+
+	stream, _ := notifier.Watch("orders", "")
+	postAll(notifier, "orders", 1)
+	token := nextChange(t, stream).Token
+	stream.Close()
+	for _, value := range []int{2, 3, 4} {
+		notifier.Post("orders", value)
+	}
+
+	other := historyNotifier(t, 2)
+	otherStream, _ := other.Watch("orders", "")
+	postAll(other, "orders", 1)
+	foreign := nextChange(t, otherStream).Token
+	otherStream.Close()
+
+	raw, _ := base64.RawURLEncoding.DecodeString(token)
+	for _, test := range []struct {
+		name  string
+		event string
+		token string
+		err   error
+	}{
+		{name: "fell out of history", event: "orders", token: token, err: notify.ErrResumeTokenExpired},
+		{name: "other notifier", event: "orders", token: foreign, err: notify.ErrResumeTokenExpired},
+		{name: "other event", event: "payments", token: token, err: notify.ErrInvalidResumeToken},
+		{name: "not base64", event: "orders", token: "not a token!", err: notify.ErrInvalidResumeToken},
+		{name: "truncated", event: "orders", token: base64.RawURLEncoding.EncodeToString(raw[:len(raw)-len(".orders")]), err: notify.ErrInvalidResumeToken},
+		{name: "future", event: "orders", token: base64.RawURLEncoding.EncodeToString(append(raw[:len(raw)-len("1.orders")], "zz.orders"...)), err: notify.ErrInvalidResumeToken},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stream, err := notifier.Watch(test.event, test.token)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Watch returned %v, want %v", err, test.err)
+			}
+			if stream != nil {
+				t.Fatal("Watch returned a stream along with its error")
+			}
+		})
+	}
+}
+
+func TestWatchCatchUpKeepsOrder(t *testing.T) {
+	clock := notifytest.NewFakeClock(time.Unix(0, 0))
+	notifier := historyNotifier(t, 100, notify.WithClock(clock))
+
+	stream, _ := notifier.Watch("orders", "")
+	postAll(notifier, "orders", 0)
+	token := nextChange(t, stream).Token
+	stream.Close()
+	for value := 1; value <= 5; value++ {
+		notifier.Post("orders", value)
+	}
+
+	resumed, err := notifier.Watch("orders", token, notify.WithCatchUpRate(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	// Posted while the backlog is being delivered
+	posted := make(chan struct{})
+	go func() {
+		defer close(posted)
+		for value := 6; value <= 8; value++ {
+			notifier.Post("orders", value)
+		}
+	}()
+
+	for want := 1; want <= 8; want++ {
+		var got notify.Change
+		select {
+		case got = <-resumed.Changes():
+		case <-time.After(10 * time.Millisecond):
+			// Held back by the catch-up rate
+			clock.Advance(time.Second)
+			got = nextChange(t, resumed)
+		}
+		if got.Data != want {
+			t.Fatalf("resumed stream delivered %v, want %d", got.Data, want)
+		}
+	}
+	select {
+	case <-posted:
+	case <-time.After(time.Second):
+		t.Fatal("posts waited on a stream catching up")
+	}
+}
+
+func TestWatchCatchUpRateBounds(t *testing.T) {
+	for _, perSecond := range []int{-1, 0, 2e9} {
+		t.Run(strconv.Itoa(perSecond), func(t *testing.T) {
+			notifier := historyNotifier(t, 10)
+			stream, _ := notifier.Watch("orders", "")
+			postAll(notifier, "orders", 0, 1, 2)
+			token := nextChange(t, stream).Token
+			nextChange(t, stream)
+			nextChange(t, stream)
+			stream.Close()
+
+			resumed, err := notifier.Watch("orders", token, notify.WithCatchUpRate(perSecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resumed.Close()
+			for want := 1; want <= 2; want++ {
+				if got := nextChange(t, resumed).Data; got != want {
+					t.Fatalf("resumed stream delivered %v, want %d", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	journal := &memJournal{}
+	clock := notifytest.NewFakeClock(time.Unix(1000, 0))
+	source := notify.NewNotifier(notify.WithClock(clock), notify.WithJournal(journal, stringCodec{}, "orders"))
+	source.Start("orders", make(chan interface{}, 10))
+	for _, order := range []string{"order 1", "order 2", "order 3"} {
+		source.Post("orders", order)
+		clock.Advance(time.Minute)
+	}
+	source.Close()
+
+	notifier := notify.NewNotifier(notify.WithJournal(journal, stringCodec{}, "orders"))
+	defer notifier.Close()
+	ready := notifier.ReadyAfterReplay()
+	outputChan := make(chan interface{}, 10)
+	notifier.Start("orders", outputChan)
+
+	if err := notifier.Replay("orders", time.Unix(1000, 0).Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"decoded order 2", "decoded order 3"} {
+		if got := <-outputChan; got != want {
+			t.Fatalf("replayed %v, want %v", got, want)
+		}
+	}
+	select {
+	case got := <-outputChan:
+		t.Fatalf("replayed %v, before from", got)
+	default:
+	}
+	select {
+	case <-ready:
+	default:
+		t.Fatal("ReadyAfterReplay not closed once the event was replayed")
+	}
+	if events := journal.events(); len(events) != 3 {
+		t.Fatalf("journal holds %v, replayed notifications journaled again", events)
+	}
+}